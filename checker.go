@@ -0,0 +1,21 @@
+package slidingwindow
+
+// NonceChecker is implemented by SlidingWindow, ConcurrentSlidingWindow, WideWindow, Window and MonotonicWindow. Code
+// that only checks nonces can accept a NonceChecker and leave the choice of implementation to its caller.
+type NonceChecker interface {
+	// CheckAndSetNonce returns true if the nonce is valid and marks it as used.
+	CheckAndSetNonce(nonce uint64) (Reason, bool)
+	// CheckNonce returns true if the nonce is valid without changing the state.
+	CheckNonce(nonce uint64) (Reason, bool)
+}
+
+// Ensure the implementations satisfy NonceChecker.
+var (
+	_ NonceChecker = (*SlidingWindow)(nil)
+	_ NonceChecker = (*ConcurrentSlidingWindow)(nil)
+	_ NonceChecker = (*WideWindow)(nil)
+	_ NonceChecker = (*MonotonicWindow)(nil)
+	_ NonceChecker = (*Window[Int128])(nil)
+	_ NonceChecker = (*Window[Int256])(nil)
+	_ NonceChecker = (*Window[Int512])(nil)
+)
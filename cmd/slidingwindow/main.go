@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/JonathanLogan/slidingwindow"
+)
+
+var jsonOutput = flag.Bool("json", false, "print the steps as a JSON array instead of a table")
+
+func main() {
+	flag.Parse()
+	nonces := argsToInt()
+	if len(nonces) == 0 {
+		fmt.Printf("Usage:\n$ %s [-json] <nonce> <nonce> <nonce>...\n\n", path.Base(os.Args[0]))
+		os.Exit(1)
+	}
+	if *jsonOutput {
+		if err := printJSON(slidingwindow.Replay(nonces)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Println("\nApplying nonces in order:", nonces)
+	fmt.Println("Nonce\tOK?\tReason\tOffset\tBitmap")
+	fmt.Println(strings.Repeat("=", 288))
+	color := colorOutput()
+	for _, step := range slidingwindow.Replay(nonces) {
+		fmt.Printf("%d\t%t\t%s\t%d\t%s\n", step.Nonce, step.OK, step.Reason, step.Offset, printWindow(step, color))
+	}
+}
+
+// IGNORE BELOW: ======================================================================
+
+// convert arguments to uint64, skipping and reporting invalid ones
+func argsToInt() []uint64 {
+	args := flag.Args()
+	if len(args) == 0 {
+		return nil
+	}
+	r := make([]uint64, len(args))
+	j := 0
+	for _, arg := range args {
+		x, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			continue
+		}
+		r[j] = x
+		j++
+	}
+	if skipped := len(args) - j; skipped > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %d invalid argument(s)\n", skipped)
+	}
+	return r[:j]
+}
+
+// print state of the window, highlighting the bit to be tested/set if color is true
+func printWindow(step slidingwindow.ReplayStep, color bool) string {
+	window := new(slidingwindow.SlidingWindow)
+	window.SetState(step.Offset, step.Bitmap)
+	return slidingwindow.RenderWindow(window, step.Nonce, color)
+}
+
+// colorOutput reports whether to use ANSI colors: only if stdout is a terminal and NO_COLOR is not set, see
+// https://no-color.org
+func colorOutput() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// jsonStep is a ReplayStep as printed by printJSON.
+type jsonStep struct {
+	Nonce  uint64               `json:"nonce"`
+	OK     bool                 `json:"ok"`
+	Reason slidingwindow.Reason `json:"reason"`
+	Offset uint64               `json:"offset"`
+	Bitmap string               `json:"bitmap"`
+}
+
+// print the steps as a JSON array, the bitmap as 64 hex digits
+func printJSON(steps []slidingwindow.ReplayStep) error {
+	out := make([]jsonStep, len(steps))
+	for i, step := range steps {
+		out[i] = jsonStep{
+			Nonce:  step.Nonce,
+			OK:     step.OK,
+			Reason: step.Reason,
+			Offset: step.Offset,
+			Bitmap: fmt.Sprintf("%x", step.Bitmap),
+		}
+	}
+	return json.NewEncoder(os.Stdout).Encode(out)
+}
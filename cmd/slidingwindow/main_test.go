@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestArgsToInt(t *testing.T) {
+	if err := flag.CommandLine.Parse([]string{"5", "x", "7", "-3", "0", "18446744073709551616", "1e3"}); err != nil {
+		t.Fatal(err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	nonces := argsToInt()
+	os.Stderr = stderr
+	w.Close()
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{5, 7, 0}; !slices.Equal(nonces, want) {
+		t.Errorf("argsToInt() = %v, want %v", nonces, want)
+	}
+	if !strings.Contains(string(output), "Skipped 4 invalid argument(s)") {
+		t.Errorf("stderr = %q, want the number of skipped arguments", output)
+	}
+}
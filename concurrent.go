@@ -0,0 +1,40 @@
+package slidingwindow
+
+import (
+	"sync"
+)
+
+// ConcurrentSlidingWindow is a SlidingWindow that is safe for concurrent use. The zero value is an empty window
+// starting at offset 0.
+type ConcurrentSlidingWindow struct {
+	mutex  sync.RWMutex
+	window SlidingWindow
+}
+
+// NewConcurrentSlidingWindow returns an empty ConcurrentSlidingWindow starting at startOffset, see NewSlidingWindow.
+func NewConcurrentSlidingWindow(startOffset uint64) *ConcurrentSlidingWindow {
+	return &ConcurrentSlidingWindow{
+		window: *NewSlidingWindow(startOffset),
+	}
+}
+
+// CheckAndSetNonce is the synchronized version of SlidingWindow.CheckAndSetNonce.
+func (cw *ConcurrentSlidingWindow) CheckAndSetNonce(nonce uint64) (Reason, bool) {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	return cw.window.CheckAndSetNonce(nonce)
+}
+
+// CheckNonce is the synchronized version of SlidingWindow.CheckNonce.
+func (cw *ConcurrentSlidingWindow) CheckNonce(nonce uint64) (Reason, bool) {
+	cw.mutex.RLock()
+	defer cw.mutex.RUnlock()
+	return cw.window.CheckNonce(nonce)
+}
+
+// SetOnShift is the synchronized version of SlidingWindow.SetOnShift. The function is called with the lock held.
+func (cw *ConcurrentSlidingWindow) SetOnShift(fn func(oldOffset, newOffset uint64)) {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	cw.window.SetOnShift(fn)
+}
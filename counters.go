@@ -0,0 +1,59 @@
+package slidingwindow
+
+import (
+	"expvar"
+	"strings"
+	"sync/atomic"
+)
+
+// Counters counts the decisions of one or more windows by Reason, see SlidingWindow.SetCounters. The counters are
+// updated atomically and can be read while the windows are in use. The zero value is ready to use.
+type Counters struct {
+	reasons  [numReasons]atomic.Uint64
+	accepted atomic.Uint64
+	rejected atomic.Uint64
+}
+
+// add counts a decision for reason with the result ok.
+func (counters *Counters) add(reason Reason, ok bool) {
+	counters.reasons[reason].Add(1)
+	if ok {
+		counters.accepted.Add(1)
+	} else {
+		counters.rejected.Add(1)
+	}
+}
+
+// Stats returns the total number of accepted and rejected nonces by the result of CheckAndSetNonce. Duplicates accepted
+// by SlidingWindow.SetAcceptIdempotent count as accepted here, while ByReason counts them as ReasonReuse.
+func (counters *Counters) Stats() (accepted, rejected uint64) {
+	return counters.accepted.Load(), counters.rejected.Load()
+}
+
+// ByReason returns a snapshot of the number of decisions per Reason.
+func (counters *Counters) ByReason() map[Reason]uint64 {
+	snapshot := make(map[Reason]uint64, len(counters.reasons))
+	for reason := range counters.reasons {
+		snapshot[Reason(reason)] = counters.reasons[reason].Load()
+	}
+	return snapshot
+}
+
+// Publish registers the counters with expvar so they appear on /debug/vars: prefix+".accepted" and prefix+".rejected"
+// hold the totals of Stats, and prefix+"." followed by the lowercase Reason name, e.g. prefix+".shift", the count per
+// Reason. Like expvar.Publish it panics if a name is already registered.
+func (counters *Counters) Publish(prefix string) {
+	expvar.Publish(prefix+".accepted", expvar.Func(func() any {
+		accepted, _ := counters.Stats()
+		return accepted
+	}))
+	expvar.Publish(prefix+".rejected", expvar.Func(func() any {
+		_, rejected := counters.Stats()
+		return rejected
+	}))
+	for reason := range counters.reasons {
+		expvar.Publish(prefix+"."+strings.ToLower(Reason(reason).String()), expvar.Func(func() any {
+			return counters.reasons[reason].Load()
+		}))
+	}
+}
@@ -0,0 +1,22 @@
+package slidingwindow
+
+import (
+	"testing"
+)
+
+func TestCountersAcceptIdempotent(t *testing.T) {
+	var counters Counters
+	window, err := New(WithCounters(&counters), WithAcceptIdempotent())
+	if err != nil {
+		t.Fatal(err)
+	}
+	window.CheckAndSetNonce(300)
+	window.CheckAndSetNonce(300)
+	window.CheckAndSetNonce(10)
+	if accepted, rejected := counters.Stats(); accepted != 2 || rejected != 1 {
+		t.Errorf("Stats() = %d, %d, want 2, 1", accepted, rejected)
+	}
+	if reuse := counters.ByReason()[ReasonReuse]; reuse != 1 {
+		t.Errorf("ByReason()[ReasonReuse] = %d, want 1", reuse)
+	}
+}
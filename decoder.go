@@ -0,0 +1,47 @@
+package slidingwindow
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrReplay is returned by Decoder.Decode for messages with a rejected nonce. It wraps the error of the Reason, so
+// errors.Is also matches ErrReused or ErrOutOfWindow.
+var ErrReplay = errors.New("slidingwindow: replayed message")
+
+// NonceCarrier is a message that carries a nonce.
+type NonceCarrier interface {
+	Nonce() uint64
+}
+
+// Decoder decodes a stream of gob-encoded messages and rejects replayed ones by their nonce. It is not synchronized.
+type Decoder struct {
+	decoder *gob.Decoder
+	window  SlidingWindow
+}
+
+// NewDecoder returns a Decoder reading from r with an empty window.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		decoder: gob.NewDecoder(r),
+	}
+}
+
+// Decode decodes the next message into v, which must be a pointer as for gob.Decoder.Decode, and checks its nonce
+// with CheckAndSetNonce. A message with a rejected nonce is still decoded into v but ErrReplay is returned.
+func (decoder *Decoder) Decode(v NonceCarrier) error {
+	if err := decoder.decoder.Decode(v); err != nil {
+		return err
+	}
+	if reason, ok := decoder.window.CheckAndSetNonce(v.Nonce()); !ok {
+		return fmt.Errorf("%w: %w", ErrReplay, reason.Err())
+	}
+	return nil
+}
+
+// Window returns the window of the decoder for inspection.
+func (decoder *Decoder) Window() *SlidingWindow {
+	return &decoder.window
+}
@@ -0,0 +1,185 @@
+package slidingwindow
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Version tags of the binary encodings of a SlidingWindow.
+const (
+	binaryVersion       = 1 // offset and bitmap
+	binaryVersionSparse = 2 // offset and positions of set bits
+)
+
+// sparseHeaderLength is the length of the sparse binary encoding without positions: version tag and offset.
+const sparseHeaderLength = 1 + 8
+
+// binaryLength is the length of the binary encoding of a SlidingWindow: version tag, offset and the four bitmap words.
+const binaryLength = 1 + 8 + 4*8
+
+// ErrInvalidLength is returned when decoding a SlidingWindow from data of the wrong length.
+var ErrInvalidLength = errors.New("slidingwindow: invalid encoding length")
+
+// ErrUnknownVersion is returned when decoding a SlidingWindow from data with an unknown version tag.
+var ErrUnknownVersion = errors.New("slidingwindow: unknown encoding version")
+
+// ErrInvalidJSON is returned when decoding a SlidingWindow from a malformed JSON object.
+var ErrInvalidJSON = errors.New("slidingwindow: invalid JSON encoding")
+
+// jsonWindow is the JSON representation of a SlidingWindow.
+type jsonWindow struct {
+	Offset *uint64 `json:"offset"`
+	Bitmap *string `json:"bitmap"`
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The encoding is 41 bytes: the version tag 1, the offset and the
+// bitmap words 0 to 3, all big-endian. The window size is configuration and not part of the encoding. Like the other
+// encoding methods it has a value receiver, so that windows stored by value, e.g. in maps, are encoded as well.
+func (window SlidingWindow) MarshalBinary() ([]byte, error) {
+	return window.AppendBinary(make([]byte, 0, binaryLength))
+}
+
+// AppendBinary implements encoding.BinaryAppender. It appends the MarshalBinary encoding to dst, allowing to encode
+// many windows into one buffer without allocations.
+func (window SlidingWindow) AppendBinary(dst []byte) ([]byte, error) {
+	dst = append(dst, make([]byte, binaryLength)...)
+	window.putBinary(dst[len(dst)-binaryLength:])
+	return dst, nil
+}
+
+// MarshalBinaryCompact returns a binary encoding of the window that is shorter than MarshalBinary for windows with few
+// accepted nonces. If fewer than 32 bits are set, the encoding is the version tag 2, the big-endian offset and one byte
+// with the position of each set bit in ascending order. Otherwise it is the MarshalBinary encoding. Both are decoded
+// by UnmarshalBinary.
+func (window *SlidingWindow) MarshalBinaryCompact() ([]byte, error) {
+	count := window.bitmap.PopCount()
+	if sparseHeaderLength+count >= binaryLength {
+		return window.MarshalBinary()
+	}
+	b := make([]byte, sparseHeaderLength, sparseHeaderLength+count)
+	b[0] = binaryVersionSparse
+	binary.BigEndian.PutUint64(b[1:9], window.offset)
+	for pos := 0; pos < MaxWindowSize; pos++ {
+		if isBitSet(window.bitmap, uint8(pos)) {
+			b = append(b, uint8(pos))
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It decodes the output of MarshalBinary and
+// MarshalBinaryCompact, detecting the encoding by its version tag. It returns ErrUnknownVersion for an unknown version
+// tag and ErrInvalidLength if data is truncated or malformed.
+func (window *SlidingWindow) UnmarshalBinary(data []byte) error {
+	if len(data) > 0 && data[0] == binaryVersionSparse {
+		return window.getSparse(data)
+	}
+	return window.getBinary(data)
+}
+
+// putBinary writes the binary encoding of the window to b, which must be binaryLength bytes long.
+func (window *SlidingWindow) putBinary(b []byte) {
+	b[0] = binaryVersion
+	binary.BigEndian.PutUint64(b[1:9], window.offset)
+	bitmap := window.bitmap.Bytes()
+	copy(b[9:], bitmap[:])
+}
+
+// getBinary reads the binary encoding of the window from b. The window is only changed if b is valid.
+func (window *SlidingWindow) getBinary(b []byte) error {
+	if len(b) > 0 && b[0] != binaryVersion {
+		return ErrUnknownVersion
+	}
+	if len(b) != binaryLength {
+		return ErrInvalidLength
+	}
+	window.offset = binary.BigEndian.Uint64(b[1:9])
+	window.bitmap, _ = SetBytes(b[9:])
+	return nil
+}
+
+// getSparse reads the sparse binary encoding of the window from b. The window is only changed if b is valid.
+func (window *SlidingWindow) getSparse(b []byte) error {
+	if len(b) < sparseHeaderLength || len(b) >= binaryLength {
+		return ErrInvalidLength
+	}
+	var bitmap Int256
+	for i, pos := range b[sparseHeaderLength:] {
+		// Positions must be strictly ascending.
+		if i > 0 && pos <= b[sparseHeaderLength+i-1] {
+			return ErrInvalidLength
+		}
+		bitmap = setBit(bitmap, pos)
+	}
+	window.offset = binary.BigEndian.Uint64(b[1:9])
+	window.bitmap = bitmap
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. The window is encoded as an object like {"offset":12345,"bitmap":"..."} where
+// bitmap is a 64 digit hex string of the bitmap words 0 to 3, big-endian. It has a value receiver so that windows
+// stored by value, e.g. in maps or struct fields, are encoded as well.
+func (window SlidingWindow) MarshalJSON() ([]byte, error) {
+	b := window.bitmap.Bytes()
+	bitmap := hex.EncodeToString(b[:])
+	return json.Marshal(jsonWindow{
+		Offset: &window.offset,
+		Bitmap: &bitmap,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Both fields are required, and the bitmap must be exactly 64 hex digits.
+func (window *SlidingWindow) UnmarshalJSON(data []byte) error {
+	var v jsonWindow
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Offset == nil || v.Bitmap == nil {
+		return ErrInvalidJSON
+	}
+	b, err := hex.DecodeString(*v.Bitmap)
+	if err != nil || len(b) != 32 {
+		return ErrInvalidJSON
+	}
+	window.offset = *v.Offset
+	window.bitmap, _ = SetBytes(b)
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder using the MarshalBinary encoding.
+func (window SlidingWindow) GobEncode() ([]byte, error) {
+	return window.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder using the UnmarshalBinary encoding.
+func (window *SlidingWindow) GobDecode(data []byte) error {
+	return window.UnmarshalBinary(data)
+}
+
+// WriteTo implements io.WriterTo. It writes the 41 byte MarshalBinary encoding to w and returns io.ErrShortWrite if w
+// accepts fewer bytes without reporting an error.
+func (window *SlidingWindow) WriteTo(w io.Writer) (int64, error) {
+	var b [binaryLength]byte
+	window.putBinary(b[:])
+	n, err := w.Write(b[:])
+	if err == nil && n < binaryLength {
+		err = io.ErrShortWrite
+	}
+	return int64(n), err
+}
+
+// ReadFrom implements io.ReaderFrom. Unlike most implementations it does not read until EOF but exactly the 41 byte
+// MarshalBinary encoding, so that multiple windows can be read from one stream. The variable length encoding of
+// MarshalBinaryCompact is not supported. A short read returns io.ErrUnexpectedEOF (or io.EOF if nothing was read) and
+// leaves the window unchanged, as does an unknown version tag.
+func (window *SlidingWindow) ReadFrom(r io.Reader) (int64, error) {
+	var b [binaryLength]byte
+	n, err := io.ReadFull(r, b[:])
+	if err != nil {
+		return int64(n), err
+	}
+	return int64(n), window.getBinary(b[:])
+}
@@ -0,0 +1,319 @@
+package slidingwindow
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+// testWindow returns a window at offset 1000 that has accepted nonces at both ends and in the middle.
+func testWindow(t *testing.T) *SlidingWindow {
+	t.Helper()
+	window := NewSlidingWindow(1000)
+	checkSteps(t, window, []step{
+		{1000, ReasonFirst, true},
+		{1100, ReasonFirst, true},
+		{1255, ReasonFirst, true},
+	})
+	return window
+}
+
+func TestBinaryEncoding(t *testing.T) {
+	window := testWindow(t)
+	data, err := window.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		1,                            // version
+		0, 0, 0, 0, 0, 0, 0x03, 0xe8, // offset 1000
+		0x80, 0, 0, 0, 0, 0, 0, 0, // bit 0
+		0, 0, 0, 0, 0x08, 0, 0, 0, // bit 100
+		0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0x01, // bit 255
+	}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("MarshalBinary() = %x, want %x", data, want)
+	}
+	decoded := new(SlidingWindow)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(window) {
+		t.Errorf("UnmarshalBinary(MarshalBinary()) = %s, want %s", decoded, window)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, ErrInvalidLength},
+		{"truncated", data[:len(data)-1], ErrInvalidLength},
+		{"trailing byte", append(data[:len(data):len(data)], 0), ErrInvalidLength},
+		{"unknown version", append([]byte{3}, data[1:]...), ErrUnknownVersion},
+	}
+	for _, test := range tests {
+		// Invalid data leaves the window unchanged.
+		decoded := testWindow(t)
+		if err := decoded.UnmarshalBinary(test.data); err != test.err {
+			t.Errorf("%s: UnmarshalBinary() error = %v, want %v", test.name, err, test.err)
+		}
+		if !decoded.Equal(window) {
+			t.Errorf("%s: UnmarshalBinary() changed the window to %s", test.name, decoded)
+		}
+	}
+}
+
+func TestJSONEncoding(t *testing.T) {
+	window := testWindow(t)
+	data, err := json.Marshal(window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"offset":1000,"bitmap":"8000000000000000000000000800000000000000000000000000000000000001"}`
+	if string(data) != want {
+		t.Fatalf("json.Marshal() = %s, want %s", data, want)
+	}
+
+	// Windows stored by value, as map values or struct fields, are encoded as well.
+	type state struct {
+		Window  SlidingWindow
+		Windows map[string]SlidingWindow
+	}
+	in := state{Window: *window, Windows: map[string]SlidingWindow{"peer": *window}}
+	data, err = json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out state
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	peer := out.Windows["peer"]
+	if !out.Window.Equal(window) || !peer.Equal(window) {
+		t.Errorf("json round trip of %s = %s and %s", window, &out.Window, &peer)
+	}
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"missing offset", `{"bitmap":"8000000000000000000000000800000000000000000000000000000000000001"}`},
+		{"missing bitmap", `{"offset":1000}`},
+		{"null bitmap", `{"offset":1000,"bitmap":null}`},
+		{"invalid hex", `{"offset":1000,"bitmap":"x000000000000000000000000800000000000000000000000000000000000001"}`},
+		{"short bitmap", `{"offset":1000,"bitmap":"80"}`},
+		{"long bitmap", `{"offset":1000,"bitmap":"800000000000000000000000080000000000000000000000000000000000000100"}`},
+	}
+	for _, test := range tests {
+		decoded := testWindow(t)
+		if err := json.Unmarshal([]byte(test.data), decoded); !errors.Is(err, ErrInvalidJSON) {
+			t.Errorf("%s: json.Unmarshal() error = %v, want %v", test.name, err, ErrInvalidJSON)
+		}
+		if !decoded.Equal(window) {
+			t.Errorf("%s: json.Unmarshal() changed the window to %s", test.name, decoded)
+		}
+	}
+	// Malformed JSON is reported by encoding/json.
+	for _, data := range []string{`{"offset":-1,"bitmap":""}`, `{"offset":"1000"}`, `[]`, `{`} {
+		if err := json.Unmarshal([]byte(data), new(SlidingWindow)); err == nil {
+			t.Errorf("json.Unmarshal(%s) returned no error", data)
+		}
+	}
+}
+
+func TestGobEncoding(t *testing.T) {
+	window := testWindow(t)
+	// The map of values of the request, gob can't take the address of map values.
+	in := map[string]SlidingWindow{"a": *window, "b": *NewSlidingWindow(5)}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]SlidingWindow
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(in) {
+		t.Fatalf("gob round trip returned %d windows, want %d", len(out), len(in))
+	}
+	for id, want := range in {
+		if got := out[id]; !got.Equal(&want) {
+			t.Errorf("gob round trip of %q = %s, want %s", id, &got, &want)
+		}
+	}
+}
+
+// shortWriter accepts at most n bytes per Write without reporting an error.
+type shortWriter struct {
+	n int
+}
+
+func (w shortWriter) Write(p []byte) (int, error) {
+	return min(len(p), w.n), nil
+}
+
+func TestStreamEncoding(t *testing.T) {
+	windows := []*SlidingWindow{testWindow(t), NewSlidingWindow(5), new(SlidingWindow)}
+	var buf bytes.Buffer
+	for _, window := range windows {
+		if n, err := window.WriteTo(&buf); n != binaryLength || err != nil {
+			t.Fatalf("WriteTo() = %d, %v, want %d, nil", n, err, binaryLength)
+		}
+	}
+	// Windows are read one after another from the same stream.
+	for i, want := range windows {
+		window := new(SlidingWindow)
+		if n, err := window.ReadFrom(&buf); n != binaryLength || err != nil {
+			t.Fatalf("ReadFrom() of window %d = %d, %v, want %d, nil", i, n, err, binaryLength)
+		}
+		if !window.Equal(want) {
+			t.Errorf("ReadFrom() of window %d = %s, want %s", i, window, want)
+		}
+	}
+
+	window := testWindow(t)
+	if n, err := window.WriteTo(shortWriter{10}); n != 10 || err != io.ErrShortWrite {
+		t.Errorf("WriteTo(short writer) = %d, %v, want 10, %v", n, err, io.ErrShortWrite)
+	}
+	data, _ := window.MarshalBinary()
+	compact, _ := window.MarshalBinaryCompact()
+	tests := []struct {
+		name string
+		data []byte
+		n    int64
+		err  error
+	}{
+		{"empty", nil, 0, io.EOF},
+		{"truncated", data[:20], 20, io.ErrUnexpectedEOF},
+		{"compact", compact, int64(len(compact)), io.ErrUnexpectedEOF},
+		{"unknown version", append([]byte{3}, data[1:]...), binaryLength, ErrUnknownVersion},
+	}
+	for _, test := range tests {
+		// Errors leave the window unchanged.
+		decoded := NewSlidingWindow(5)
+		if n, err := decoded.ReadFrom(bytes.NewReader(test.data)); n != test.n || err != test.err {
+			t.Errorf("%s: ReadFrom() = %d, %v, want %d, %v", test.name, n, err, test.n, test.err)
+		}
+		if !decoded.Equal(NewSlidingWindow(5)) {
+			t.Errorf("%s: ReadFrom() changed the window to %s", test.name, decoded)
+		}
+	}
+}
+
+func TestBinaryVersion(t *testing.T) {
+	data, err := testWindow(t).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[0] != binaryVersion {
+		t.Fatalf("MarshalBinary() version = %d, want %d", data[0], binaryVersion)
+	}
+	for version := 0; version < 256; version++ {
+		// Version 2 is the sparse encoding, which is never as long as the full one.
+		want := ErrUnknownVersion
+		switch version {
+		case binaryVersion:
+			want = nil
+		case binaryVersionSparse:
+			want = ErrInvalidLength
+		}
+		data[0] = byte(version)
+		if err := new(SlidingWindow).UnmarshalBinary(data); err != want {
+			t.Errorf("UnmarshalBinary() with version %d: error = %v, want %v", version, err, want)
+		}
+	}
+}
+
+func TestAppendBinary(t *testing.T) {
+	window := testWindow(t)
+	data, err := window.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Windows are appended after the existing content, in the layout of MarshalBinary.
+	buf := []byte("prefix")
+	for i := 0; i < 3; i++ {
+		if buf, err = window.AppendBinary(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := append([]byte("prefix"), bytes.Repeat(data, 3)...); !bytes.Equal(buf, want) {
+		t.Errorf("AppendBinary() = %x, want %x", buf, want)
+	}
+
+	buf = make([]byte, 0, 10*binaryLength)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, _ = window.AppendBinary(buf[:0])
+	})
+	if allocs != 0 {
+		t.Errorf("AppendBinary() into a large enough buffer allocated %.0f times", allocs)
+	}
+}
+
+func TestBinaryCompact(t *testing.T) {
+	window := testWindow(t)
+	data, err := window.MarshalBinaryCompact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		2,                            // version
+		0, 0, 0, 0, 0, 0, 0x03, 0xe8, // offset 1000
+		0, 100, 255, // positions
+	}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("MarshalBinaryCompact() = %x, want %x", data, want)
+	}
+
+	// The sparse encoding is used while it is shorter, up to 31 set bits.
+	for _, count := range []int{0, 1, 31, 32, MaxWindowSize} {
+		window := NewSlidingWindow(1000)
+		for n := 0; n < count; n++ {
+			window.CheckAndSetNonce(uint64(1000 + n*MaxWindowSize/count))
+		}
+		data, err := window.MarshalBinaryCompact()
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantVersion, wantLength := byte(binaryVersionSparse), sparseHeaderLength+count
+		if count >= 32 {
+			wantVersion, wantLength = binaryVersion, binaryLength
+		}
+		if data[0] != wantVersion || len(data) != wantLength {
+			t.Errorf("%d bits: MarshalBinaryCompact() has version %d and length %d, want %d and %d",
+				count, data[0], len(data), wantVersion, wantLength)
+		}
+		decoded := new(SlidingWindow)
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%d bits: UnmarshalBinary() error = %v", count, err)
+		}
+		if !decoded.Equal(window) {
+			t.Errorf("%d bits: UnmarshalBinary(MarshalBinaryCompact()) = %s, want %s", count, decoded, window)
+		}
+	}
+
+	header := data[:sparseHeaderLength]
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated header", header[:sparseHeaderLength-1]},
+		{"descending positions", append(header[:sparseHeaderLength:sparseHeaderLength], 100, 5)},
+		{"duplicate position", append(header[:sparseHeaderLength:sparseHeaderLength], 5, 5)},
+		{"as long as full encoding", append(header[:sparseHeaderLength:sparseHeaderLength], make([]byte, 32)...)},
+	}
+	for _, test := range tests {
+		decoded := testWindow(t)
+		if err := decoded.UnmarshalBinary(test.data); err != ErrInvalidLength {
+			t.Errorf("%s: UnmarshalBinary() error = %v, want %v", test.name, err, ErrInvalidLength)
+		}
+		if !decoded.Equal(window) {
+			t.Errorf("%s: UnmarshalBinary() changed the window to %s", test.name, decoded)
+		}
+	}
+}
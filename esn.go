@@ -0,0 +1,63 @@
+package slidingwindow
+
+// ExtendSequenceNumber reconstructs a 64-bit Extended Sequence Number (ESN) from its low 32 bits as received on the wire,
+// the highest accepted sequence number and the window size, following RFC 4303 Appendix A.
+//
+// The low bits are ambiguous: the same value can belong to any 2^32 block. They are resolved to the unique sequence
+// number in [highest-windowSize+1, highest-windowSize+1+2^32), so anything from the bottom of the window up to almost
+// 2^32 above it. Sequence numbers that are further ahead alias to a smaller one, sequence numbers below the window
+// alias to a larger one. While highest is in the first 2^32 block nothing can be below it, so the low bits are used
+// as-is.
+func ExtendSequenceNumber(low uint32, highest uint64, windowSize int) uint64 {
+	th, tl := highest>>32, uint32(highest)
+	bottom := tl - uint32(windowSize) + 1
+	seqh := th
+	if tl >= uint32(windowSize)-1 {
+		// Window is within one 2^32 block, low bits below it belong to the next block.
+		if low < bottom {
+			seqh++
+		}
+	} else if low >= bottom && th > 0 {
+		// Window spans two 2^32 blocks, large low bits belong to the previous block.
+		seqh--
+	}
+	return seqh<<32 | uint64(low)
+}
+
+// CheckAndSetNonceESN reconstructs the 64-bit sequence number from its low 32 bits using ExtendSequenceNumber with
+// the highest accepted nonce and the window size, and then calls CheckAndSetNonce with it. It additionally returns the
+// reconstructed sequence number, which is needed to verify the integrity of ESP packets.
+func (window *SlidingWindow) CheckAndSetNonceESN(low uint32) (Reason, uint64, bool) {
+	highest := window.HighestNonce()
+	if window.offset == 0 && window.bitmap.IsZero() {
+		// Fresh window, nothing accepted yet.
+		highest = 0
+	}
+	nonce := ExtendSequenceNumber(low, highest, window.WindowSize())
+	reason, ok := window.CheckAndSetNonce(nonce)
+	return reason, nonce, ok
+}
+
+// CheckAndSetNonce32 is CheckAndSetNonce for 32-bit sequence numbers that wrap around after 0xFFFFFFFF. The sequence
+// number is promoted to 64 bits using the position of the window: its upper 32 bits count the wraps, so no separate
+// epoch counter has to be kept in sync.
+//
+// A sequence number is taken as moving forward if it is less than 2^31 ahead of the highest accepted one (modulo 2^32,
+// as in RFC 1982 serial number arithmetic), and as moving backward otherwise. This way 0xFFFFFFFF followed by 0 is a
+// wrap, while a replayed low number long after the wrap is checked against the window and rejected.
+func (window *SlidingWindow) CheckAndSetNonce32(seq uint32) (Reason, bool) {
+	if window.offset == 0 && window.bitmap.IsZero() {
+		// Fresh window, nothing to be relative to.
+		return window.CheckAndSetNonce(uint64(seq))
+	}
+	highest := window.HighestNonce()
+	diff := int32(seq - uint32(highest))
+	if diff >= 0 {
+		return window.CheckAndSetNonce(highest + uint64(diff))
+	}
+	back := uint64(-int64(diff))
+	if back > highest {
+		return ReasonOutOfWindow, false
+	}
+	return window.CheckAndSetNonce(highest - back)
+}
@@ -0,0 +1,104 @@
+package slidingwindow
+
+import (
+	"testing"
+)
+
+func TestExtendSequenceNumber(t *testing.T) {
+	const block = 5 << 32
+	tests := []struct {
+		name       string
+		low        uint32
+		highest    uint64
+		windowSize int
+		want       uint64
+	}{
+		// Case A of RFC 4303 Appendix A: the window 745 to 1000 is within one block.
+		{"A highest", 1000, block | 1000, 256, block | 1000},
+		{"A bottom", 745, block | 1000, 256, block | 745},
+		{"A below bottom", 744, block | 1000, 256, 6<<32 | 744},
+		{"A ahead", 0xffffffff, block | 1000, 256, block | 0xffffffff},
+		{"A next block", 0, block | 1000, 256, 6<<32 | 0},
+		{"A small window", 936, block | 1000, 64, 6<<32 | 936},
+		{"A window at block start", 0, block | 255, 256, block | 0},
+		// Case B: the window 0xffffff65 to 100 spans the blocks 4 and 5.
+		{"B highest", 100, block | 100, 256, block | 100},
+		{"B block start", 0, block | 100, 256, block | 0},
+		{"B ahead", 101, block | 100, 256, block | 101},
+		{"B previous block", 0xffffffff, block | 100, 256, 4<<32 | 0xffffffff},
+		{"B bottom", 0xffffff65, block | 100, 256, 4<<32 | 0xffffff65},
+		{"B below bottom", 0xffffff64, block | 100, 256, block | 0xffffff64},
+		// In the first block there is no previous one, the low bits are used as-is.
+		{"first block", 0xffffffff, 100, 256, 0xffffffff},
+		{"first block start", 50, 100, 256, 50},
+	}
+	for _, test := range tests {
+		if got := ExtendSequenceNumber(test.low, test.highest, test.windowSize); got != test.want {
+			t.Errorf("%s: ExtendSequenceNumber(%#x, %#x, %d) = %#x, want %#x",
+				test.name, test.low, test.highest, test.windowSize, got, test.want)
+		}
+	}
+}
+
+func TestCheckAndSetNonceESN(t *testing.T) {
+	window := new(SlidingWindow)
+	tests := []struct {
+		low    uint32
+		reason Reason
+		nonce  uint64
+		ok     bool
+	}{
+		// A fresh window takes the low bits as-is.
+		{0xfffffff0, ReasonShift, 0xfffffff0, true},
+		{0, ReasonShift, 1 << 32, true},
+		{0xfffffff5, ReasonFirst, 0xfffffff5, true},
+		{0xfffffff0, ReasonReuse, 0xfffffff0, false},
+		{0, ReasonReuse, 1 << 32, false},
+		{1, ReasonShift, 1<<32 | 1, true},
+	}
+	for _, test := range tests {
+		reason, nonce, ok := window.CheckAndSetNonceESN(test.low)
+		if reason != test.reason || nonce != test.nonce || ok != test.ok {
+			t.Errorf("CheckAndSetNonceESN(%#x) = %s, %#x, %t, want %s, %#x, %t",
+				test.low, reason, nonce, ok, test.reason, test.nonce, test.ok)
+		}
+	}
+
+	window = new(SlidingWindow)
+	if reason, nonce, ok := window.CheckAndSetNonceESN(7); reason != ReasonFirst || nonce != 7 || !ok {
+		t.Errorf("CheckAndSetNonceESN(7) on a fresh window = %s, %d, %t, want %s, 7, true", reason, nonce, ok, ReasonFirst)
+	}
+}
+
+func TestCheckAndSetNonce32Wrap(t *testing.T) {
+	window := new(SlidingWindow)
+	tests := []struct {
+		seq    uint32
+		reason Reason
+		ok     bool
+	}{
+		// Climb through the wrap.
+		{0xfffffff0, ReasonShift, true},
+		{0xfffffffe, ReasonShift, true},
+		{0xffffffff, ReasonShift, true},
+		{0, ReasonShift, true},
+		{1, ReasonShift, true},
+		{3, ReasonShift, true},
+		// Replays just after the wrap, from both sides of it.
+		{0xffffffff, ReasonReuse, false},
+		{0, ReasonReuse, false},
+		{1, ReasonReuse, false},
+		{0xfffffff0, ReasonReuse, false},
+		// Late but new.
+		{2, ReasonFirst, true},
+		{0xfffffff1, ReasonFirst, true},
+	}
+	for _, test := range tests {
+		if reason, ok := window.CheckAndSetNonce32(test.seq); reason != test.reason || ok != test.ok {
+			t.Errorf("CheckAndSetNonce32(%#x) = %s, %t, want %s, %t", test.seq, reason, ok, test.reason, test.ok)
+		}
+	}
+	if highest := window.HighestNonce(); highest != 1<<32+3 {
+		t.Errorf("HighestNonce() = %#x, want %#x", highest, uint64(1<<32+3))
+	}
+}
@@ -1,3 +1,3 @@
-module slidingwindow
+module github.com/JonathanLogan/slidingwindow
 
 go 1.22.4
@@ -0,0 +1,45 @@
+package slidingwindow
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ReplayGuard returns an http.Handler that protects next against replayed requests. Each request must carry a decimal
+// nonce in the named header, which is checked against a window per client. The client is identified by key, e.g. by
+// returning the API key of the request. key is called concurrently and outside of the lock shared by all requests, so a
+// slow lookup only delays its own request. Requests with a missing or malformed nonce are answered with 400 Bad
+// Request, rejected nonces with 409 Conflict.
+//
+// Windows of clients that have been idle for ttl are removed, see WindowSet.PruneBefore, so the memory used is bounded
+// by the number of clients active within ttl. A removed client starts over with a fresh window that accepts its old
+// nonces again, so requests older than ttl must be rejected by other means, e.g. a signed timestamp. A ttl of 0 keeps
+// all windows forever, which is only safe for a bounded set of keys.
+func ReplayGuard(next http.Handler, header string, key func(r *http.Request) string, ttl time.Duration) http.Handler {
+	var mutex sync.Mutex
+	var windows WindowSet
+	lastPrune := time.Now()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce, err := strconv.ParseUint(r.Header.Get(header), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid nonce", http.StatusBadRequest)
+			return
+		}
+		id := key(r)
+		mutex.Lock()
+		// Pruning walks all windows, doing it at most once per ttl keeps the cost per request constant.
+		if now := time.Now(); ttl > 0 && now.Sub(lastPrune) >= ttl {
+			windows.PruneBefore(now.Add(-ttl))
+			lastPrune = now
+		}
+		reason, ok := windows.CheckAndSetNonce(id, nonce)
+		mutex.Unlock()
+		if !ok {
+			http.Error(w, "nonce rejected: "+reason.String(), http.StatusConflict)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
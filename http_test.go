@@ -0,0 +1,73 @@
+package slidingwindow
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReplayGuard(t *testing.T) {
+	const ttl = 10 * time.Millisecond
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	guard := ReplayGuard(next, "Nonce", func(r *http.Request) string { return r.Header.Get("Client") }, ttl)
+	request := func(client, nonce string, want int) {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Client", client)
+		r.Header.Set("Nonce", nonce)
+		w := httptest.NewRecorder()
+		guard.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("client %q nonce %q: status %d, want %d", client, nonce, w.Code, want)
+		}
+	}
+	request("a", "x", http.StatusBadRequest)
+	request("a", "5", http.StatusOK)
+	request("a", "5", http.StatusConflict)
+	request("b", "5", http.StatusOK)
+	// After ttl the idle window of a is pruned by the next request and its nonces are accepted again.
+	time.Sleep(2 * ttl)
+	request("b", "6", http.StatusOK)
+	request("a", "5", http.StatusOK)
+}
+
+func TestReplayGuardSlowKey(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	guard := ReplayGuard(next, "Nonce", func(r *http.Request) string {
+		client := r.Header.Get("Client")
+		if client == "slow" {
+			close(entered)
+			<-release
+		}
+		return client
+	}, 0)
+	serve := func(client string) <-chan int {
+		done := make(chan int, 1)
+		go func() {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Client", client)
+			r.Header.Set("Nonce", "1")
+			w := httptest.NewRecorder()
+			guard.ServeHTTP(w, r)
+			done <- w.Code
+		}()
+		return done
+	}
+	slow := serve("slow")
+	<-entered
+	// A slow key lookup must not block the requests of other clients.
+	select {
+	case code := <-serve("fast"):
+		if code != http.StatusOK {
+			t.Errorf("fast client: status %d, want %d", code, http.StatusOK)
+		}
+	case <-time.After(time.Second):
+		t.Error("fast client blocked by the key lookup of another request")
+	}
+	close(release)
+	if code := <-slow; code != http.StatusOK {
+		t.Errorf("slow client: status %d, want %d", code, http.StatusOK)
+	}
+}
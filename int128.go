@@ -0,0 +1,27 @@
+package slidingwindow
+
+// Int128 is a simple 128 bit integer type with the bit layout of Int256. It backs Window[Int128], tracking 128 nonces.
+type Int128 [2]uint64
+
+// SetBit returns i with bit number n set. Bit 0 is the most significant bit of word 0, bit 127 the least significant
+// bit of word 1. It panics if n is larger.
+func (i Int128) SetBit(n uint8) Int128 {
+	setBitWords(i[:], uint64(n))
+	return i
+}
+
+// IsSet returns true if bit number n is set in i, see SetBit for the numbering.
+func (i Int128) IsSet(n uint8) bool {
+	return isBitSetWords(i[:], uint64(n))
+}
+
+// ShiftLeft returns i bit-shifted by a bits to the left, towards bit 0.
+func (i Int128) ShiftLeft(a uint64) Int128 {
+	shiftLeftWords(i[:], a)
+	return i
+}
+
+// WordCount returns the number of 64 bit words of i, 2.
+func (i Int128) WordCount() int {
+	return len(i)
+}
@@ -0,0 +1,59 @@
+package slidingwindow
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInt128SetBit(t *testing.T) {
+	// Bit n is set in word n/64, counting from the most significant bit.
+	tests := []struct {
+		bit  uint8
+		word int
+		want uint64
+	}{
+		{0, 0, 0x8000000000000000},
+		{1, 0, 0x4000000000000000},
+		{63, 0, 0x0000000000000001},
+		{64, 1, 0x8000000000000000},
+		{100, 1, 0x0000000008000000},
+		{127, 1, 0x0000000000000001},
+	}
+	for _, test := range tests {
+		var want Int128
+		want[test.word] = test.want
+		got := Int128{}.SetBit(test.bit)
+		if got != want {
+			t.Errorf("SetBit(%d) = %x, want %x", test.bit, got, want)
+		}
+		for n := 0; n < 128; n++ {
+			if got.IsSet(uint8(n)) != (n == int(test.bit)) {
+				t.Errorf("SetBit(%d).IsSet(%d) = %t", test.bit, n, got.IsSet(uint8(n)))
+			}
+		}
+	}
+}
+
+func TestInt128ShiftLeft(t *testing.T) {
+	i := Int128{0x0123456789abcdef, 0x8000000000000001}
+	tests := []struct {
+		shift uint64
+		want  Int128
+	}{
+		{0, Int128{0x0123456789abcdef, 0x8000000000000001}},
+		{1, Int128{0x02468acf13579bdf, 0x0000000000000002}},
+		{63, Int128{0xc000000000000000, 0x8000000000000000}},
+		{64, Int128{0x8000000000000001, 0x0000000000000000}},
+		{65, Int128{0x0000000000000002, 0x0000000000000000}},
+		{127, Int128{0x8000000000000000, 0x0000000000000000}},
+		// Shifts of the width or more clear all bits.
+		{128, Int128{}},
+		{129, Int128{}},
+		{math.MaxUint64, Int128{}},
+	}
+	for _, test := range tests {
+		if got := i.ShiftLeft(test.shift); got != test.want {
+			t.Errorf("%x.ShiftLeft(%d) = %x, want %x", i, test.shift, got, test.want)
+		}
+	}
+}
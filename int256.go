@@ -0,0 +1,381 @@
+package slidingwindow
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Int256 is a simple 256 bit integer type.
+type Int256 [4]uint64
+
+// ErrInt256Overflow is returned when a value does not fit into an Int256.
+var ErrInt256Overflow = errors.New("slidingwindow: value exceeds 256 bits")
+
+// ErrInvalidInt256 is returned when parsing a malformed Int256 hex string.
+var ErrInvalidInt256 = errors.New("slidingwindow: invalid Int256 hex string")
+
+// shiftLeft bit-shifts i by a bits to the left.
+func shiftLeft(i Int256, a uint64) Int256 {
+	// Note: Not branch optimized. Idiomatic code commented for clarity.
+	// shift full words
+	switch a / 64 {
+	case 0:
+		break
+	case 1:
+		//i[0], i[1], i[2], i[3] = i[1], i[2], i[3], 0
+		i[0] = i[1]
+		i[1] = i[2]
+		i[2] = i[3]
+		i[3] = 0
+	case 2:
+		//i[0], i[1], i[2], i[3] =  i[2], i[3], 0, 0
+		i[0] = i[2]
+		i[1] = i[3]
+		i[2] = 0
+		i[3] = 0
+	case 3:
+		//i[0], i[1], i[2], i[3] =  i[3], 0, 0, 0
+		i[0] = i[3]
+		i[1] = 0
+		i[2] = 0
+		i[3] = 0
+	default:
+		//i[0], i[1], i[2], i[3] = 0, 0, 0, 0
+		i[0] = 0
+		i[1] = 0
+		i[2] = 0
+		i[3] = 0
+	}
+	// shift remaining bits, nothing to merge for whole word shifts
+	b := a % 64
+	if b == 0 {
+		return i
+	}
+	i[0] = (i[0] << b) | (i[1] >> (64 - b))
+	i[1] = (i[1] << b) | (i[2] >> (64 - b))
+	i[2] = (i[2] << b) | (i[3] >> (64 - b))
+	i[3] = i[3] << b
+	return i
+}
+
+// shiftRight bit-shifts i by a bits to the right.
+func shiftRight(i Int256, a uint64) Int256 {
+	// shift full words
+	switch a / 64 {
+	case 0:
+		break
+	case 1:
+		//i[0], i[1], i[2], i[3] = 0, i[0], i[1], i[2]
+		i[3] = i[2]
+		i[2] = i[1]
+		i[1] = i[0]
+		i[0] = 0
+	case 2:
+		//i[0], i[1], i[2], i[3] = 0, 0, i[0], i[1]
+		i[3] = i[1]
+		i[2] = i[0]
+		i[1] = 0
+		i[0] = 0
+	case 3:
+		//i[0], i[1], i[2], i[3] = 0, 0, 0, i[0]
+		i[3] = i[0]
+		i[2] = 0
+		i[1] = 0
+		i[0] = 0
+	default:
+		//i[0], i[1], i[2], i[3] = 0, 0, 0, 0
+		i[0] = 0
+		i[1] = 0
+		i[2] = 0
+		i[3] = 0
+	}
+	// shift remaining bits, nothing to merge for whole word shifts
+	b := a % 64
+	if b == 0 {
+		return i
+	}
+	i[3] = (i[3] >> b) | (i[2] << (64 - b))
+	i[2] = (i[2] >> b) | (i[1] << (64 - b))
+	i[1] = (i[1] >> b) | (i[0] << (64 - b))
+	i[0] = i[0] >> b
+	return i
+}
+
+// setBit sets bit number a in i. Count starts at 0.
+func setBit(i Int256, a uint8) Int256 {
+	return i.SetBit(a)
+}
+
+// clearBit clears bit number a in i. Count starts at 0.
+func clearBit(i Int256, a uint8) Int256 {
+	return i.ClearBit(a)
+}
+
+// SetBitLE is SetBit with little-endian bit numbering within words for interoperability: bit n is bit n%64 of word
+// n/64, counted from the least significant bit.
+func (i Int256) SetBitLE(n uint8) Int256 {
+	i[n/64] = i[n/64] | 0x01<<(n%64)
+	return i
+}
+
+// ClearBitLE is ClearBit with little-endian bit numbering within words, see SetBitLE.
+func (i Int256) ClearBitLE(n uint8) Int256 {
+	i[n/64] = i[n/64] &^ (0x01 << (n % 64))
+	return i
+}
+
+// IsSetLE is IsSet with little-endian bit numbering within words, see SetBitLE.
+func (i Int256) IsSetLE(n uint8) bool {
+	return i[n/64]&(0x01<<(n%64)) != 0
+}
+
+// ReverseBits converts between the default bit numbering and the little-endian bit numbering of SetBitLE by reversing
+// the bits of each word: i.IsSet(n) == i.ReverseBits().IsSetLE(n). Use it to exchange bitmaps, like those of
+// SlidingWindow.Bitmap and SetState, with systems numbering bits the other way.
+func (i Int256) ReverseBits() Int256 {
+	return Int256{bits.Reverse64(i[0]), bits.Reverse64(i[1]), bits.Reverse64(i[2]), bits.Reverse64(i[3])}
+}
+
+// toggleBit flips bit number a in i. Count starts at 0.
+func toggleBit(i Int256, a uint8) Int256 {
+	i[a/64] = i[a/64] ^ 0x01<<(63-(a%64))
+	return i
+}
+
+// setBitRange sets bits number from to to in i, inclusive. Count starts at 0. Nothing is set if from > to.
+func setBitRange(i Int256, from, to uint8) Int256 {
+	for w := range i {
+		lo, hi := max(int(from), w*64), min(int(to), w*64+63)
+		if lo > hi {
+			continue
+		}
+		i[w] = i[w] | rangeMask(lo-w*64, hi-w*64)
+	}
+	return i
+}
+
+// rangeMask returns a word with bits number lo to hi set, inclusive. Count starts at 0 with the most significant bit.
+func rangeMask(lo, hi int) uint64 {
+	return (^uint64(0) >> lo) & (^uint64(0) << (63 - hi))
+}
+
+// isBitSet returns true if bit number a is true in i. Count starts at 0.
+func isBitSet(i Int256, a uint8) bool {
+	return i.IsSet(a)
+}
+
+// SetBit returns i with bit number n set. Bit 0 is the most significant bit of word 0, bit 255 the least significant
+// bit of word 3.
+func (i Int256) SetBit(n uint8) Int256 {
+	i[n/64] = i[n/64] | 0x01<<(63-(n%64))
+	return i
+}
+
+// ClearBit returns i with bit number n cleared, see SetBit for the numbering.
+func (i Int256) ClearBit(n uint8) Int256 {
+	i[n/64] = i[n/64] &^ (0x01 << (63 - (n % 64)))
+	return i
+}
+
+// IsSet returns true if bit number n is set in i, see SetBit for the numbering.
+func (i Int256) IsSet(n uint8) bool {
+	return i[n/64]&(0x01<<(63-(n%64))) != 0
+}
+
+// ShiftLeft returns i bit-shifted by a bits to the left, towards bit 0.
+func (i Int256) ShiftLeft(a uint64) Int256 {
+	return shiftLeft(i, a)
+}
+
+// WordCount returns the number of 64 bit words of i, 4.
+func (i Int256) WordCount() int {
+	return len(i)
+}
+
+// And returns the bitwise AND of i and j.
+func (i Int256) And(j Int256) Int256 {
+	return Int256{i[0] & j[0], i[1] & j[1], i[2] & j[2], i[3] & j[3]}
+}
+
+// Or returns the bitwise OR of i and j.
+func (i Int256) Or(j Int256) Int256 {
+	return Int256{i[0] | j[0], i[1] | j[1], i[2] | j[2], i[3] | j[3]}
+}
+
+// Xor returns the bitwise XOR of i and j.
+func (i Int256) Xor(j Int256) Int256 {
+	return Int256{i[0] ^ j[0], i[1] ^ j[1], i[2] ^ j[2], i[3] ^ j[3]}
+}
+
+// Not returns the bitwise complement of i.
+func (i Int256) Not() Int256 {
+	return Int256{^i[0], ^i[1], ^i[2], ^i[3]}
+}
+
+// PopCount returns the number of set bits in i.
+func (i Int256) PopCount() int {
+	return bits.OnesCount64(i[0]) + bits.OnesCount64(i[1]) + bits.OnesCount64(i[2]) + bits.OnesCount64(i[3])
+}
+
+// LeadingZeros returns the number of zero bits before the first set bit, starting at bit 0 (the most significant bit of
+// word 0). It returns 256 if i is zero.
+func (i Int256) LeadingZeros() int {
+	for w := 0; w < len(i); w++ {
+		if i[w] != 0 {
+			return w*64 + bits.LeadingZeros64(i[w])
+		}
+	}
+	return 256
+}
+
+// TrailingZeros returns the number of zero bits after the last set bit, ending at bit 255 (the least significant bit of
+// word 3). It returns 256 if i is zero.
+func (i Int256) TrailingZeros() int {
+	for w := len(i) - 1; w >= 0; w-- {
+		if i[w] != 0 {
+			return (len(i)-1-w)*64 + bits.TrailingZeros64(i[w])
+		}
+	}
+	return 256
+}
+
+// Cmp compares i and j as unsigned integers with word 0 being most significant. It returns -1 if i < j, 0 if i == j
+// and +1 if i > j.
+func (i Int256) Cmp(j Int256) int {
+	for w := 0; w < len(i); w++ {
+		switch {
+		case i[w] < j[w]:
+			return -1
+		case i[w] > j[w]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// Equal returns true if i and j have the same bits set.
+func (i Int256) Equal(j Int256) bool {
+	return i[0] == j[0] && i[1] == j[1] && i[2] == j[2] && i[3] == j[3]
+}
+
+// IsZero returns true if no bit is set in i.
+func (i Int256) IsZero() bool {
+	return i[0]|i[1]|i[2]|i[3] == 0
+}
+
+// Bytes returns i as 32 big-endian bytes, word 0 first.
+func (i Int256) Bytes() [32]byte {
+	var b [32]byte
+	for w, word := range i {
+		binary.BigEndian.PutUint64(b[w*8:w*8+8], word)
+	}
+	return b
+}
+
+// SetBytes returns the Int256 for up to 32 big-endian bytes as produced by Bytes. Shorter input is left-padded with
+// zeros, longer input returns ErrInt256Overflow.
+func SetBytes(b []byte) (Int256, error) {
+	var i Int256
+	if len(b) > 32 {
+		return i, ErrInt256Overflow
+	}
+	var padded [32]byte
+	copy(padded[32-len(b):], b)
+	for w := range i {
+		i[w] = binary.BigEndian.Uint64(padded[w*8 : w*8+8])
+	}
+	return i, nil
+}
+
+// Format implements fmt.Formatter. %x and %X print 64 hex digits and %b prints 256 binary digits, word 0 first, so
+// that bit 0 is the first digit. %s prints compact hex without leading zeros, like "0x1f". Other verbs format the
+// four words as an array.
+func (i Int256) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'x':
+		fmt.Fprintf(f, "%016x%016x%016x%016x", i[0], i[1], i[2], i[3])
+	case 'X':
+		fmt.Fprintf(f, "%016X%016X%016X%016X", i[0], i[1], i[2], i[3])
+	case 'b':
+		fmt.Fprintf(f, "%064b%064b%064b%064b", i[0], i[1], i[2], i[3])
+	case 's':
+		hex := strings.TrimLeft(fmt.Sprintf("%x", i), "0")
+		if hex == "" {
+			hex = "0"
+		}
+		fmt.Fprint(f, "0x"+hex)
+	default:
+		fmt.Fprintf(f, fmt.FormatString(f, verb), [4]uint64(i))
+	}
+}
+
+// ParseInt256 parses up to 64 hex digits with an optional "0x" prefix, as printed by the %x and %s verbs. Shorter
+// strings are right-aligned, so "0x1" sets bit 255. More than 64 digits return ErrInt256Overflow, an empty or
+// malformed string returns ErrInvalidInt256.
+func ParseInt256(s string) (Int256, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s = s[2:]
+	}
+	if len(s) == 0 {
+		return Int256{}, ErrInvalidInt256
+	}
+	if len(s) > 64 {
+		return Int256{}, ErrInt256Overflow
+	}
+	b, err := hex.DecodeString(strings.Repeat("0", 64-len(s)) + s)
+	if err != nil {
+		return Int256{}, ErrInvalidInt256
+	}
+	return SetBytes(b)
+}
+
+// CountRange returns the number of set bits from bit number from to bit number to in i, inclusive. Count starts at 0.
+// It returns 0 if from > to.
+func (i Int256) CountRange(from, to uint8) int {
+	var count int
+	for w := range i {
+		lo, hi := max(int(from), w*64), min(int(to), w*64+63)
+		if lo > hi {
+			continue
+		}
+		count += bits.OnesCount64(i[w] & rangeMask(lo-w*64, hi-w*64))
+	}
+	return count
+}
+
+// RotateLeft returns i rotated left by n bits across all 256 bits: bits shifted out at bit 0 reappear at bit 255.
+func (i Int256) RotateLeft(n uint) Int256 {
+	n %= 256
+	if n == 0 {
+		return i
+	}
+	return shiftLeft(i, uint64(n)).Or(shiftRight(i, uint64(256-n)))
+}
+
+// RotateRight returns i rotated right by n bits across all 256 bits: bits shifted out at bit 255 reappear at bit 0.
+func (i Int256) RotateRight(n uint) Int256 {
+	return i.RotateLeft(256 - n%256)
+}
+
+// Add returns i+j as unsigned integers with word 0 being most significant, and true if the sum overflowed 256 bits.
+func (i Int256) Add(j Int256) (Int256, bool) {
+	var carry uint64
+	for w := len(i) - 1; w >= 0; w-- {
+		i[w], carry = bits.Add64(i[w], j[w], carry)
+	}
+	return i, carry != 0
+}
+
+// Sub returns i-j as unsigned integers with word 0 being most significant, and true if the difference needed a borrow,
+// i.e. j > i.
+func (i Int256) Sub(j Int256) (Int256, bool) {
+	var borrow uint64
+	for w := len(i) - 1; w >= 0; w-- {
+		i[w], borrow = bits.Sub64(i[w], j[w], borrow)
+	}
+	return i, borrow != 0
+}
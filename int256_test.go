@@ -0,0 +1,176 @@
+package slidingwindow
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShiftLeftWholeWords(t *testing.T) {
+	i := Int256{0x0123456789abcdef, 0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000001}
+	tests := []struct {
+		shift uint64
+		want  Int256
+	}{
+		{64, Int256{i[1], i[2], i[3], 0}},
+		{128, Int256{i[2], i[3], 0, 0}},
+		{192, Int256{i[3], 0, 0, 0}},
+	}
+	for _, test := range tests {
+		if got := shiftLeft(i, test.shift); got != test.want {
+			t.Errorf("shiftLeft(%x, %d) = %x, want %x", i, test.shift, got, test.want)
+		}
+	}
+}
+
+func TestSetBitRange(t *testing.T) {
+	tests := []struct {
+		from, to uint8
+	}{
+		{0, 0},
+		{0, 63},
+		{60, 70},   // crosses word 0 to 1
+		{63, 64},   // last bit of word 0 and first of word 1
+		{64, 127},  // exactly word 1
+		{100, 200}, // spans words 1 to 3
+		{0, 255},
+		{255, 255},
+		{70, 60}, // empty
+	}
+	for _, test := range tests {
+		var want Int256
+		for n := int(test.from); n <= int(test.to); n++ {
+			want = setBit(want, uint8(n))
+		}
+		if got := setBitRange(Int256{}, test.from, test.to); got != want {
+			t.Errorf("setBitRange(0, %d, %d) = %x, want %x", test.from, test.to, got, want)
+		}
+	}
+}
+
+func TestToggleBit(t *testing.T) {
+	i := Int256{0x0123456789abcdef, 0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000001}
+	tests := []struct {
+		bit  uint8
+		want Int256
+	}{
+		{0, Int256{0x8123456789abcdef, 0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000001}},
+		{7, Int256{0x0023456789abcdef, 0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000001}},
+		{63, Int256{0x0123456789abcdee, 0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000001}},
+		{64, Int256{0x0123456789abcdef, 0x7edcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000001}},
+		{192, Int256{0x0123456789abcdef, 0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x0000000000000001}},
+		{255, Int256{0x0123456789abcdef, 0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000000}},
+	}
+	for _, test := range tests {
+		got := toggleBit(i, test.bit)
+		if got != test.want {
+			t.Errorf("toggleBit(%x, %d) = %x, want %x", i, test.bit, got, test.want)
+		}
+		// Toggling twice restores the original.
+		if back := toggleBit(got, test.bit); back != i {
+			t.Errorf("toggleBit(toggleBit(%x, %d), %d) = %x", i, test.bit, test.bit, back)
+		}
+	}
+}
+
+func TestShiftLeft(t *testing.T) {
+	i := Int256{0x0123456789abcdef, 0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000001}
+	tests := []struct {
+		shift uint64
+		want  Int256
+	}{
+		{0, Int256{0x0123456789abcdef, 0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000001}},
+		{1, Int256{0x02468acf13579bdf, 0xfdb97530eca86420, 0x1e1e1e1e1e1e1e1f, 0x0000000000000002}},
+		{63, Int256{0xff6e5d4c3b2a1908, 0x0787878787878787, 0xc000000000000000, 0x8000000000000000}},
+		{64, Int256{0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000001, 0x0000000000000000}},
+		{65, Int256{0xfdb97530eca86420, 0x1e1e1e1e1e1e1e1f, 0x0000000000000002, 0x0000000000000000}},
+		{127, Int256{0x0787878787878787, 0xc000000000000000, 0x8000000000000000, 0x0000000000000000}},
+		{128, Int256{0x0f0f0f0f0f0f0f0f, 0x8000000000000001, 0x0000000000000000, 0x0000000000000000}},
+		{192, Int256{0x8000000000000001, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000}},
+		{255, Int256{0x8000000000000000, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000}},
+		// Shifts of the window size or more clear all bits.
+		{256, Int256{}},
+		{257, Int256{}},
+		{320, Int256{}},
+		{math.MaxUint64, Int256{}},
+	}
+	for _, test := range tests {
+		if got := shiftLeft(i, test.shift); got != test.want {
+			t.Errorf("shiftLeft(%x, %d) = %x, want %x", i, test.shift, got, test.want)
+		}
+	}
+	all := Int256{}.Not()
+	for _, shift := range []uint64{256, 300, 1 << 32} {
+		if got := shiftLeft(all, shift); !got.IsZero() {
+			t.Errorf("shiftLeft(%x, %d) = %x, want 0", all, shift, got)
+		}
+	}
+}
+
+func TestShiftRight(t *testing.T) {
+	i := Int256{0x0123456789abcdef, 0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000001}
+	tests := []struct {
+		shift uint64
+		want  Int256
+	}{
+		{0, Int256{0x0123456789abcdef, 0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000001}},
+		{1, Int256{0x0091a2b3c4d5e6f7, 0xff6e5d4c3b2a1908, 0x0787878787878787, 0xc000000000000000}},
+		{63, Int256{0x0000000000000000, 0x02468acf13579bdf, 0xfdb97530eca86420, 0x1e1e1e1e1e1e1e1f}},
+		{64, Int256{0x0000000000000000, 0x0123456789abcdef, 0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f}},
+		{65, Int256{0x0000000000000000, 0x0091a2b3c4d5e6f7, 0xff6e5d4c3b2a1908, 0x0787878787878787}},
+		{127, Int256{0x0000000000000000, 0x0000000000000000, 0x02468acf13579bdf, 0xfdb97530eca86420}},
+		{128, Int256{0x0000000000000000, 0x0000000000000000, 0x0123456789abcdef, 0xfedcba9876543210}},
+		{192, Int256{0x0000000000000000, 0x0000000000000000, 0x0000000000000000, 0x0123456789abcdef}},
+		{256, Int256{}},
+		{math.MaxUint64, Int256{}},
+	}
+	for _, test := range tests {
+		if got := shiftRight(i, test.shift); got != test.want {
+			t.Errorf("shiftRight(%x, %d) = %x, want %x", i, test.shift, got, test.want)
+		}
+	}
+
+	// Shifting back only loses the bits pushed off the end, bits 0 to n-1.
+	all := Int256{}.Not()
+	for _, x := range []Int256{i, all} {
+		for n := uint64(0); n <= 256; n++ {
+			want := x
+			for pos := uint64(0); pos < n; pos++ {
+				want = clearBit(want, uint8(pos))
+			}
+			if got := shiftRight(shiftLeft(x, n), n); got != want {
+				t.Errorf("shiftRight(shiftLeft(%x, %d), %d) = %x, want %x", x, n, n, got, want)
+			}
+		}
+	}
+}
+
+func TestAddSub(t *testing.T) {
+	const m = math.MaxUint64
+	max := Int256{m, m, m, m}
+	one := Int256{0, 0, 0, 1}
+	tests := []struct {
+		i, j  Int256
+		sum   Int256
+		carry bool
+	}{
+		{Int256{}, Int256{}, Int256{}, false},
+		// The carry propagates across all words.
+		{max, one, Int256{}, true},
+		{max, max, Int256{m, m, m, m - 1}, true},
+		// Carries at word boundaries.
+		{Int256{0, 0, 0, m}, one, Int256{0, 0, 1, 0}, false},
+		{Int256{0, m, m, m}, one, Int256{1, 0, 0, 0}, false},
+		// Largest sums without overflow.
+		{Int256{m >> 1, m, m, m}, Int256{1 << 63, 0, 0, 0}, max, false},
+		{Int256{m, m, m, m - 1}, one, max, false},
+	}
+	for _, test := range tests {
+		if sum, carry := test.i.Add(test.j); sum != test.sum || carry != test.carry {
+			t.Errorf("%x.Add(%x) = %x, %t, want %x, %t", test.i, test.j, sum, carry, test.sum, test.carry)
+		}
+		// Sub undoes Add, borrowing exactly if Add carried.
+		if diff, borrow := test.sum.Sub(test.j); diff != test.i || borrow != test.carry {
+			t.Errorf("%x.Sub(%x) = %x, %t, want %x, %t", test.sum, test.j, diff, borrow, test.i, test.carry)
+		}
+	}
+}
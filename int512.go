@@ -0,0 +1,27 @@
+package slidingwindow
+
+// Int512 is a simple 512 bit integer type with the bit layout of Int256. It backs Window[Int512], tracking 512 nonces.
+type Int512 [8]uint64
+
+// SetBit returns i with bit number n set. Bit 0 is the most significant bit of word 0, bit 511 the least significant
+// bit of word 7. It panics if n is larger.
+func (i Int512) SetBit(n uint16) Int512 {
+	setBitWords(i[:], uint64(n))
+	return i
+}
+
+// IsSet returns true if bit number n is set in i, see SetBit for the numbering.
+func (i Int512) IsSet(n uint16) bool {
+	return isBitSetWords(i[:], uint64(n))
+}
+
+// ShiftLeft returns i bit-shifted by a bits to the left, towards bit 0.
+func (i Int512) ShiftLeft(a uint64) Int512 {
+	shiftLeftWords(i[:], a)
+	return i
+}
+
+// WordCount returns the number of 64 bit words of i, 8.
+func (i Int512) WordCount() int {
+	return len(i)
+}
@@ -0,0 +1,110 @@
+package slidingwindow
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInt512SetBit(t *testing.T) {
+	// Bit n is set in word n/64, counting from the most significant bit.
+	tests := []struct {
+		bit  uint16
+		word int
+		want uint64
+	}{
+		{0, 0, 0x8000000000000000},
+		{1, 0, 0x4000000000000000},
+		{63, 0, 0x0000000000000001},
+		{64, 1, 0x8000000000000000},
+		{255, 3, 0x0000000000000001},
+		{256, 4, 0x8000000000000000},
+		{300, 4, 0x0000000000080000},
+		{511, 7, 0x0000000000000001},
+	}
+	for _, test := range tests {
+		var want Int512
+		want[test.word] = test.want
+		got := Int512{}.SetBit(test.bit)
+		if got != want {
+			t.Errorf("SetBit(%d) = %x, want %x", test.bit, got, want)
+		}
+		for n := 0; n < 512; n++ {
+			if got.IsSet(uint16(n)) != (n == int(test.bit)) {
+				t.Errorf("SetBit(%d).IsSet(%d) = %t", test.bit, n, got.IsSet(uint16(n)))
+			}
+		}
+	}
+}
+
+func TestInt512ShiftLeft(t *testing.T) {
+	i := Int512{
+		0x0123456789abcdef, 0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000001,
+		0xdeadbeefcafebabe, 0x0000000000000001, 0x8000000000000000, 0xffffffff00000001,
+	}
+	tests := []struct {
+		shift uint64
+		want  Int512
+	}{
+		{0, Int512{
+			0x0123456789abcdef, 0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000001,
+			0xdeadbeefcafebabe, 0x0000000000000001, 0x8000000000000000, 0xffffffff00000001,
+		}},
+		{1, Int512{
+			0x02468acf13579bdf, 0xfdb97530eca86420, 0x1e1e1e1e1e1e1e1f, 0x0000000000000003,
+			0xbd5b7ddf95fd757c, 0x0000000000000003, 0x0000000000000001, 0xfffffffe00000002,
+		}},
+		{63, Int512{
+			0xff6e5d4c3b2a1908, 0x0787878787878787, 0xc000000000000000, 0xef56df77e57f5d5f,
+			0x0000000000000000, 0xc000000000000000, 0x7fffffff80000000, 0x8000000000000000,
+		}},
+		{64, Int512{
+			0xfedcba9876543210, 0x0f0f0f0f0f0f0f0f, 0x8000000000000001, 0xdeadbeefcafebabe,
+			0x0000000000000001, 0x8000000000000000, 0xffffffff00000001, 0x0000000000000000,
+		}},
+		{65, Int512{
+			0xfdb97530eca86420, 0x1e1e1e1e1e1e1e1f, 0x0000000000000003, 0xbd5b7ddf95fd757c,
+			0x0000000000000003, 0x0000000000000001, 0xfffffffe00000002, 0x0000000000000000,
+		}},
+		{127, Int512{
+			0x0787878787878787, 0xc000000000000000, 0xef56df77e57f5d5f, 0x0000000000000000,
+			0xc000000000000000, 0x7fffffff80000000, 0x8000000000000000, 0x0000000000000000,
+		}},
+		{128, Int512{
+			0x0f0f0f0f0f0f0f0f, 0x8000000000000001, 0xdeadbeefcafebabe, 0x0000000000000001,
+			0x8000000000000000, 0xffffffff00000001, 0x0000000000000000, 0x0000000000000000,
+		}},
+		{192, Int512{
+			0x8000000000000001, 0xdeadbeefcafebabe, 0x0000000000000001, 0x8000000000000000,
+			0xffffffff00000001, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000,
+		}},
+		{255, Int512{
+			0xef56df77e57f5d5f, 0x0000000000000000, 0xc000000000000000, 0x7fffffff80000000,
+			0x8000000000000000, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000,
+		}},
+		{256, Int512{
+			0xdeadbeefcafebabe, 0x0000000000000001, 0x8000000000000000, 0xffffffff00000001,
+			0x0000000000000000, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000,
+		}},
+		{320, Int512{
+			0x0000000000000001, 0x8000000000000000, 0xffffffff00000001, 0x0000000000000000,
+			0x0000000000000000, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000,
+		}},
+		{448, Int512{
+			0xffffffff00000001, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000,
+			0x0000000000000000, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000,
+		}},
+		{511, Int512{
+			0x8000000000000000, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000,
+			0x0000000000000000, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000,
+		}},
+		// Shifts of the width or more clear all bits.
+		{512, Int512{}},
+		{513, Int512{}},
+		{math.MaxUint64, Int512{}},
+	}
+	for _, test := range tests {
+		if got := i.ShiftLeft(test.shift); got != test.want {
+			t.Errorf("%x.ShiftLeft(%d) = %x, want %x", i, test.shift, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package slidingwindow
+
+// MonotonicWindow is a lightweight alternative to SlidingWindow for strictly monotonic senders that never reorder. It
+// only keeps the highest accepted nonce and accepts larger nonces with ReasonFirst, rejects the highest one with
+// ReasonReuse and anything lower with ReasonOutOfWindow. The zero value accepts any first nonce. It is not
+// synchronized.
+type MonotonicWindow struct {
+	start    uint64 // lowest valid nonce while nothing has been accepted
+	highest  uint64
+	accepted bool
+}
+
+// NewMonotonicWindow returns a MonotonicWindow rejecting nonces below startOffset, see NewSlidingWindow.
+func NewMonotonicWindow(startOffset uint64) *MonotonicWindow {
+	return &MonotonicWindow{
+		start: startOffset,
+	}
+}
+
+// CheckAndSetNonce returns true if the nonce is valid, false otherwise. It updates the MonotonicWindow to prevent the
+// nonce and all lower ones from being valid in the future.
+func (window *MonotonicWindow) CheckAndSetNonce(nonce uint64) (Reason, bool) {
+	reason, ok := window.CheckNonce(nonce)
+	if ok {
+		window.highest = nonce
+		window.accepted = true
+	}
+	return reason, ok
+}
+
+// CheckNonce returns true if the nonce is valid. It does not change the state.
+func (window *MonotonicWindow) CheckNonce(nonce uint64) (Reason, bool) {
+	switch {
+	case !window.accepted && nonce >= window.start:
+		return ReasonFirst, true
+	case !window.accepted:
+		return ReasonOutOfWindow, false
+	case nonce > window.highest:
+		return ReasonFirst, true
+	case nonce == window.highest:
+		return ReasonReuse, false
+	}
+	return ReasonOutOfWindow, false
+}
+
+// HighestNonce returns the highest accepted nonce. If no nonce has been accepted it returns startOffset-1, like
+// SlidingWindow.HighestNonce.
+func (window *MonotonicWindow) HighestNonce() uint64 {
+	if !window.accepted {
+		return window.start - 1
+	}
+	return window.highest
+}
+
+// Reset returns the window to the state of new(MonotonicWindow).
+func (window *MonotonicWindow) Reset() {
+	*window = MonotonicWindow{}
+}
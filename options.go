@@ -0,0 +1,95 @@
+package slidingwindow
+
+// Option configures a SlidingWindow created by New.
+type Option func(*options)
+
+// options collects the configuration of New.
+type options struct {
+	startOffset    uint64
+	size           int
+	rejectZero     bool
+	detectReorder  bool
+	idempotent     bool
+	resetThreshold int
+	counters       *Counters
+	onShift        func(oldOffset, newOffset uint64)
+}
+
+// New returns an empty SlidingWindow configured by opts. Without options it is equivalent to new(SlidingWindow). It
+// returns ErrUnsupportedWindowSize if the window size is not between 1 and MaxWindowSize.
+func New(opts ...Option) (*SlidingWindow, error) {
+	o := options{
+		size: DefaultWindowSize,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	window, err := NewSlidingWindowSize(o.startOffset, o.size)
+	if err != nil {
+		return nil, err
+	}
+	window.SetRejectZero(o.rejectZero)
+	window.SetDetectReorder(o.detectReorder)
+	window.SetAcceptIdempotent(o.idempotent)
+	window.SetResetThreshold(o.resetThreshold)
+	window.SetCounters(o.counters)
+	window.SetOnShift(o.onShift)
+	return window, nil
+}
+
+// WithWindowSize sets the number of nonces tracked by the window, see NewSlidingWindowSize.
+func WithWindowSize(size int) Option {
+	return func(o *options) {
+		o.size = size
+	}
+}
+
+// WithStartOffset sets the offset of the window, see NewSlidingWindow.
+func WithStartOffset(startOffset uint64) Option {
+	return func(o *options) {
+		o.startOffset = startOffset
+	}
+}
+
+// WithRejectZero makes the window reject nonce 0, see SlidingWindow.SetRejectZero.
+func WithRejectZero() Option {
+	return func(o *options) {
+		o.rejectZero = true
+	}
+}
+
+// WithDetectReorder makes the window report ReasonReorder, see SlidingWindow.SetDetectReorder.
+func WithDetectReorder() Option {
+	return func(o *options) {
+		o.detectReorder = true
+	}
+}
+
+// WithAcceptIdempotent makes the window accept nonces already seen within it, see SlidingWindow.SetAcceptIdempotent.
+func WithAcceptIdempotent() Option {
+	return func(o *options) {
+		o.idempotent = true
+	}
+}
+
+// WithResetThreshold makes the window reset after threshold consecutive nonces below it. This weakens replay
+// protection, see SlidingWindow.SetResetThreshold.
+func WithResetThreshold(threshold int) Option {
+	return func(o *options) {
+		o.resetThreshold = threshold
+	}
+}
+
+// WithCounters makes the window count its decisions, see SlidingWindow.SetCounters.
+func WithCounters(counters *Counters) Option {
+	return func(o *options) {
+		o.counters = counters
+	}
+}
+
+// WithOnShift sets a function called when the window shifts, see SlidingWindow.SetOnShift.
+func WithOnShift(fn func(oldOffset, newOffset uint64)) Option {
+	return func(o *options) {
+		o.onShift = fn
+	}
+}
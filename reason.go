@@ -0,0 +1,87 @@
+package slidingwindow
+
+import (
+	"errors"
+)
+
+// Reason explains why the sliding window has made a decision.
+type Reason uint8
+
+const (
+	ReasonFirst Reason = iota
+	ReasonReuse
+	ReasonShift
+	ReasonOutOfWindow
+	ReasonReorder // accepted within the window but lower than the previous accepted nonce, see SetDetectReorder
+
+	numReasons = iota
+)
+
+var (
+	// ErrReused is returned by Reason.Err for ReasonReuse.
+	ErrReused = errors.New("slidingwindow: nonce reused")
+	// ErrOutOfWindow is returned by Reason.Err for ReasonOutOfWindow.
+	ErrOutOfWindow = errors.New("slidingwindow: nonce out of window")
+	// ErrUnknownReason is returned when parsing an unknown Reason name.
+	ErrUnknownReason = errors.New("slidingwindow: unknown reason")
+)
+
+func (r Reason) String() string {
+	switch r {
+	case ReasonFirst:
+		return "First"
+	case ReasonReuse:
+		return "Reuse"
+	case ReasonShift:
+		return "Shift"
+	case ReasonOutOfWindow:
+		return "Small"
+	case ReasonReorder:
+		return "Reorder"
+	}
+	return "Unknown"
+}
+
+// Err returns ErrReused or ErrOutOfWindow if r rejects a nonce, nil otherwise.
+func (r Reason) Err() error {
+	switch r {
+	case ReasonReuse:
+		return ErrReused
+	case ReasonOutOfWindow:
+		return ErrOutOfWindow
+	}
+	return nil
+}
+
+// ParseReason returns the Reason named s as returned by String. It returns ErrUnknownReason for any other name,
+// including "Unknown".
+func ParseReason(s string) (Reason, error) {
+	switch s {
+	case "First":
+		return ReasonFirst, nil
+	case "Reuse":
+		return ReasonReuse, nil
+	case "Shift":
+		return ReasonShift, nil
+	case "Small":
+		return ReasonOutOfWindow, nil
+	case "Reorder":
+		return ReasonReorder, nil
+	}
+	return 0, ErrUnknownReason
+}
+
+// MarshalText implements encoding.TextMarshaler using the names returned by String.
+func (r Reason) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseReason.
+func (r *Reason) UnmarshalText(text []byte) error {
+	reason, err := ParseReason(string(text))
+	if err != nil {
+		return err
+	}
+	*r = reason
+	return nil
+}
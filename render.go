@@ -0,0 +1,56 @@
+package slidingwindow
+
+import (
+	"fmt"
+	"math"
+)
+
+// RenderWindow returns the bitmap of the window as a string of 256 '0' and '1' characters, bit 0 first. If color is
+// true, ANSI escape codes are added for terminal output: set and clear bits are shaded differently and the bit for the
+// nonce highlight is printed red, if it is within the window.
+func RenderWindow(window *SlidingWindow, highlight uint64, color bool) string {
+	s := fmt.Sprintf("%b", window.bitmap)
+	if !color {
+		return s
+	}
+	if highlight < window.offset || highlight-window.offset >= MaxWindowSize {
+		return blurString(s, math.MaxInt)
+	}
+	return blurString(s, int(highlight-window.offset))
+}
+
+// blurString colors the '0' and '1' characters of s with ANSI escape codes and prints the character at bitPos red.
+func blurString(s string, bitPos int) string {
+	var one, zero, red, end = []byte("\u001B[0;37m"), []byte("\u001B[1;30m"), []byte("\033[0;31m"), []byte("\033[0m")
+	var last byte
+	color := func(b byte) []byte {
+		switch b {
+		case '1':
+			return one
+		case '0':
+			return zero
+		}
+		return []byte{}
+	}
+	a := make([]byte, 0, len(s)+4)
+	last = s[0]
+	a = append(a, color(last)...)
+	for p, b := range []byte(s) {
+		if p == bitPos {
+			a = append(a, end...)
+			a = append(a, red...)
+			a = append(a, b)
+			a = append(a, end...)
+			last = 0x00
+			continue
+		}
+		if b != last {
+			a = append(a, end...)
+			a = append(a, color(b)...)
+			last = b
+		}
+		a = append(a, b)
+	}
+	a = append(a, end...)
+	return string(a)
+}
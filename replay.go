@@ -0,0 +1,28 @@
+package slidingwindow
+
+// ReplayStep is the outcome of one nonce in Replay: the decision and the window state after it.
+type ReplayStep struct {
+	Nonce  uint64
+	Reason Reason
+	OK     bool
+	Offset uint64
+	Bitmap Int256
+}
+
+// Replay applies nonces in order to a fresh SlidingWindow and returns each decision together with the resulting state,
+// e.g. to assert the full trajectory in tests or to render it like the slidingwindow command does.
+func Replay(nonces []uint64) []ReplayStep {
+	window := new(SlidingWindow)
+	steps := make([]ReplayStep, len(nonces))
+	for i, nonce := range nonces {
+		reason, ok := window.CheckAndSetNonce(nonce)
+		steps[i] = ReplayStep{
+			Nonce:  nonce,
+			Reason: reason,
+			OK:     ok,
+			Offset: window.offset,
+			Bitmap: window.bitmap,
+		}
+	}
+	return steps
+}
@@ -1,227 +1,631 @@
-package main
+// Package slidingwindow implements a sliding window algorithm for nonce replay protection.
+package slidingwindow
 
 import (
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"math"
-	"os"
-	"path"
-	"strconv"
-	"strings"
+	"math/bits"
 )
 
-// ALGO ===================
+// DefaultWindowSize is the number of nonces tracked by a SlidingWindow unless configured otherwise.
+const DefaultWindowSize = 256
 
-// SlidingWindow implements a sliding window algorithm.
+// MaxWindowSize is the largest window size supported by SlidingWindow, limited by the Int256 bitmap. Use WideWindow
+// for larger windows.
+const MaxWindowSize = 256
+
+// ErrUnsupportedWindowSize is returned when creating a window with a size that its bitmap can't support.
+var ErrUnsupportedWindowSize = errors.New("slidingwindow: unsupported window size")
+
+// ErrInvalidNonceLength is returned for byte encoded nonces that are not 8 bytes long.
+var ErrInvalidNonceLength = errors.New("slidingwindow: nonce must be 8 bytes")
+
+// ErrInvalidState is returned by Verify for a window state that can't result from valid operations.
+var ErrInvalidState = errors.New("slidingwindow: invalid window state")
+
+// SlidingWindow implements a sliding window algorithm. Without options it makes the same decisions as a Window[Int256]
+// and shares its implementation. It is not synchronized, use ConcurrentSlidingWindow when sharing a window between
+// goroutines.
 type SlidingWindow struct {
-	offset uint64
-	bitmap Int256
+	core          // offset and size, where size 0 means DefaultWindowSize
+	bitmap Int256 // words passed to core
+
+	rejectZero bool      // nonce 0 is never valid
+	counters   *Counters // optional, counts decisions of CheckAndSetNonce
+
+	onShift func(oldOffset, newOffset uint64) // optional, called when the window shifts
+
+	detectReorder bool   // report ReasonReorder
+	lastAccepted  uint64 // last accepted nonce if detectReorder is set
+
+	acceptIdempotent bool // ReasonReuse is reported as valid
+
+	lastNonce  uint64 // nonce, reason and result of the last CheckAndSetNonce
+	lastReason Reason
+	lastOK     bool
+
+	resetThreshold int    // consecutive nonces below the window that reset it, 0 disables
+	lowStreak      int    // consecutive nonces below the window so far
+	lowStreakMin   uint64 // smallest nonce of the current streak
+}
+
+// NewSlidingWindow returns an empty SlidingWindow starting at startOffset, e.g. a persisted high-water mark. Nonces
+// below startOffset are rejected with ReasonOutOfWindow. The window covers the DefaultWindowSize (256) nonces
+// startOffset to startOffset+255, any larger nonce shifts the window as usual.
+func NewSlidingWindow(startOffset uint64) *SlidingWindow {
+	return &SlidingWindow{
+		core: core{
+			offset: startOffset,
+			size:   DefaultWindowSize,
+		},
+	}
+}
+
+// NewSlidingWindowSize returns an empty SlidingWindow starting at startOffset that tracks size nonces, covering
+// startOffset to startOffset+size-1. It returns ErrUnsupportedWindowSize unless size is between 1 and MaxWindowSize.
+func NewSlidingWindowSize(startOffset uint64, size int) (*SlidingWindow, error) {
+	if size < 1 || size > MaxWindowSize {
+		return nil, ErrUnsupportedWindowSize
+	}
+	return &SlidingWindow{
+		core: core{
+			offset: startOffset,
+			size:   uint64(size),
+		},
+	}, nil
+}
+
+// NewRFC6479Window returns an empty SlidingWindow following the anti-replay rules of RFC 6479 (IPsec ESP) for a
+// replay window of windowSize:
+//   - Sequence number 0 is never valid and rejected with ReasonOutOfWindow.
+//   - A sequence number is valid if it is at least the highest accepted one minus windowSize, so the leftmost edge
+//     itself is still accepted. This needs windowSize+1 bits.
+//   - Larger sequence numbers first advance the window and are then tested, so they are always accepted.
+//
+// The RFC's block-based bitmap avoids shifting but makes the same decisions. It returns ErrUnsupportedWindowSize unless
+// windowSize is between 1 and MaxWindowSize-1.
+func NewRFC6479Window(windowSize int) (*SlidingWindow, error) {
+	if windowSize < 1 || windowSize >= MaxWindowSize {
+		return nil, ErrUnsupportedWindowSize
+	}
+	window, err := NewSlidingWindowSize(0, windowSize+1)
+	if err != nil {
+		return nil, err
+	}
+	window.rejectZero = true
+	return window, nil
 }
 
 // CheckAndSetNonce returns true if the nonce is valid, false otherwise. It updates the SlidingWindow to prevent the nonce
 // from being valid in the future.
 func (window *SlidingWindow) CheckAndSetNonce(nonce uint64) (Reason, bool) {
-	const windowSize = 256
-	// Is the nonce on the left of the window and hence invalid?
-	if nonce < window.offset {
-		return ReasonOutOfWindow, false
+	// Decide like CheckNonce, side effects are only applied here.
+	reason, ok := window.decide(nonce, window.offset)
+	// Reserved nonces are never valid, they neither count towards a streak nor end it.
+	if window.resetThreshold > 0 && !(nonce == 0 && window.rejectZero) {
+		if reason == ReasonOutOfWindow && nonce < window.offset {
+			if window.lowStreak == 0 || nonce < window.lowStreakMin {
+				window.lowStreakMin = nonce
+			}
+			window.lowStreak++
+			if window.lowStreak >= window.resetThreshold {
+				// The sender restarted, start over from the smallest nonce of the streak.
+				window.ResetTo(window.lowStreakMin)
+				reason, ok = window.decide(nonce, window.offset)
+			}
+		} else {
+			window.lowStreak = 0
+		}
+	}
+	switch reason {
+	case ReasonShift, ReasonFirst, ReasonReorder:
+		oldOffset := window.set(window.bitmap[:], nonce)
+		if reason == ReasonShift && window.onShift != nil {
+			window.onShift(oldOffset, window.offset)
+		}
+	}
+	if ok && window.detectReorder && reason != ReasonReuse {
+		window.lastAccepted = nonce
+	}
+	window.lastNonce, window.lastReason, window.lastOK = nonce, reason, ok
+	if window.counters != nil {
+		window.counters.add(reason, ok)
+	}
+	return reason, ok
+}
+
+// CheckAndSetNonceShift is CheckAndSetNonce but additionally returns the number of positions the window has been
+// shifted, which is 0 unless the reason is ReasonShift. A reset by SetResetThreshold moves the window backwards and
+// also reports 0.
+func (window *SlidingWindow) CheckAndSetNonceShift(nonce uint64) (Reason, uint64, bool) {
+	oldOffset := window.offset
+	reason, ok := window.CheckAndSetNonce(nonce)
+	if window.offset < oldOffset {
+		return reason, 0, ok
+	}
+	return reason, window.offset - oldOffset, ok
+}
+
+// CheckAndSetNonceBit is CheckAndSetNonce but additionally returns whether the bit of the nonce was set before the call.
+// It is set for ReasonReuse, even if SetAcceptIdempotent accepts the nonce, and clear for ReasonFirst, ReasonReorder
+// and ReasonShift; anything else indicates a bug. Nonces below the window have no bit and report false.
+func (window *SlidingWindow) CheckAndSetNonceBit(nonce uint64) (Reason, bool, bool) {
+	var wasSet bool
+	if nonce >= window.offset && nonce-window.offset < uint64(window.WindowSize()) {
+		wasSet = isBitSet(window.bitmap, uint8(nonce-window.offset))
+	}
+	reason, ok := window.CheckAndSetNonce(nonce)
+	return reason, wasSet, ok
+}
+
+// CheckAndSetNonceTx is CheckAndSetNonce but additionally returns a function that reverts the acceptance of the nonce,
+// restoring the offset and any bits shifted out. If the nonce was rejected the function does nothing. Only the most
+// recent operation can be reverted safely, reverting restores the state from before the call and so undoes any later
+// changes, too.
+func (window *SlidingWindow) CheckAndSetNonceTx(nonce uint64) (Reason, bool, func()) {
+	snapshot := window.Snapshot()
+	reason, ok := window.CheckAndSetNonce(nonce)
+	if !ok {
+		return reason, ok, func() {}
+	}
+	return reason, ok, func() {
+		window.Restore(snapshot)
+	}
+}
+
+// CheckAndSetNonces applies CheckAndSetNonce to nonces in order and returns whether each nonce was accepted. Order
+// matters: a nonce that shifts the window can make later, smaller nonces of the same batch out of window.
+func (window *SlidingWindow) CheckAndSetNonces(nonces []uint64) []bool {
+	results := make([]bool, len(nonces))
+	for i, nonce := range nonces {
+		_, results[i] = window.CheckAndSetNonce(nonce)
+	}
+	return results
+}
+
+// ctxCheckInterval is the number of nonces CheckAndSetNoncesCtx processes between checks for cancellation.
+const ctxCheckInterval = 1024
+
+// CheckAndSetNoncesCtx is CheckAndSetNonces for long batches that can be cancelled by ctx. It returns the reasons for
+// the nonces applied before ctx got cancelled together with ctx.Err(). The window state reflects exactly these nonces.
+func (window *SlidingWindow) CheckAndSetNoncesCtx(ctx context.Context, nonces []uint64) ([]Reason, error) {
+	reasons := make([]Reason, 0, len(nonces))
+	for i, nonce := range nonces {
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return reasons, err
+			}
+		}
+		reason, _ := window.CheckAndSetNonce(nonce)
+		reasons = append(reasons, reason)
 	}
-	// Is the nonce on the right of the window? If yes, shift window and update offset.
-	if nonce >= window.offset+windowSize {
-		newOffset := nonce - windowSize + 1
-		shift := newOffset - window.offset
-		window.offset = newOffset
-		window.bitmap = shiftLeft(window.bitmap, shift)
-		window.bitmap = setBit(window.bitmap, uint8(nonce-window.offset))
-		return ReasonShift, true
+	return reasons, nil
+}
+
+// CheckAndSetNonceBytes is CheckAndSetNonce for a nonce encoded as 8 big-endian bytes. It returns
+// ErrInvalidNonceLength for any other length.
+func (window *SlidingWindow) CheckAndSetNonceBytes(b []byte) (Reason, bool, error) {
+	if len(b) != 8 {
+		return 0, false, ErrInvalidNonceLength
 	}
-	// Nonce is within the window.
-	bitPos := uint8(nonce - window.offset)
-	if isBitSet(window.bitmap, bitPos) {
-		return ReasonReuse, false
+	reason, ok := window.CheckAndSetNonce(binary.BigEndian.Uint64(b))
+	return reason, ok, nil
+}
+
+// CheckNonceBytes is CheckNonce for a nonce encoded as 8 big-endian bytes. It returns ErrInvalidNonceLength for any
+// other length.
+func (window *SlidingWindow) CheckNonceBytes(b []byte) (Reason, bool, error) {
+	if len(b) != 8 {
+		return 0, false, ErrInvalidNonceLength
 	}
-	window.bitmap = setBit(window.bitmap, bitPos)
-	return ReasonFirst, true
+	reason, ok := window.CheckNonce(binary.BigEndian.Uint64(b))
+	return reason, ok, nil
 }
 
 // CheckNonce returns true if the nonce is valid. It does not change the state.
 func (window *SlidingWindow) CheckNonce(nonce uint64) (Reason, bool) {
-	const windowSize = 256
-	// Is the nonce on the left of the window and hence invalid?
-	if nonce < window.offset {
+	return window.decide(nonce, window.offset)
+}
+
+// CheckNonceWithin returns true if the nonce would be valid if the window started at offset, using the current bitmap.
+// It does not change the state.
+func (window *SlidingWindow) CheckNonceWithin(nonce, offset uint64) (Reason, bool) {
+	return window.decide(nonce, offset)
+}
+
+// decide returns the decision for nonce if the window started at offset. It is shared by CheckNonce and
+// CheckAndSetNonce so they can't disagree, and must not change the state.
+func (window *SlidingWindow) decide(nonce, offset uint64) (Reason, bool) {
+	// Is the nonce reserved and hence invalid?
+	if nonce == 0 && window.rejectZero {
 		return ReasonOutOfWindow, false
 	}
-	// Is the nonce on the right of the window? If yes, shift window and update offset.
-	if nonce >= window.offset+windowSize {
-		return ReasonShift, true
-	}
-	// Nonce is within the window.
-	bitPos := uint8(nonce - window.offset)
-	if isBitSet(window.bitmap, bitPos) {
-		return ReasonReuse, false
-	}
-	return ReasonFirst, true
-}
-
-// ALGO END ===============
-
-// BIT STUFF ==============
-
-// Int256 is a simple 256 bit integer type.
-type Int256 [4]uint64
-
-// shiftLeft bit-shifts i by a bits to the left.
-func shiftLeft(i Int256, a uint64) Int256 {
-	// Note: Not branch optimized. Idiomatic code commented for clarity.
-	// shift full words
-	switch a / 64 {
-	case 0:
-		break
-	case 1:
-		//i[0], i[1], i[2], i[3] = i[1], i[2], i[3], 0
-		i[0] = i[1]
-		i[1] = i[2]
-		i[2] = i[3]
-		i[3] = 0
-	case 2:
-		//i[0], i[1], i[2], i[3] =  i[2], i[3], 0, 0
-		i[0] = i[2]
-		i[1] = i[3]
-		i[2] = 0
-		i[3] = 0
-	case 3:
-		//i[0], i[1], i[2], i[3] =  i[3], 0, 0, 0
-		i[0] = i[3]
-		i[1] = 0
-		i[2] = 0
-		i[3] = 0
-	default:
-		//i[0], i[1], i[2], i[3] = 0, 0, 0, 0
-		i[0] = 0
-		i[1] = 0
-		i[2] = 0
-		i[3] = 0
-	}
-	// shift remaining bits
-	b := a % 64
-	i[0] = (i[0] << b) | (i[1] >> (64 - b))
-	i[1] = (i[1] << b) | (i[2] >> (64 - b))
-	i[2] = (i[2] << b) | (i[3] >> (64 - b))
-	i[3] = i[3] << b
-	return i
-}
-
-// setBit sets bit number a in i. Count starts at 0.
-func setBit(i Int256, a uint8) Int256 {
-	i[a/64] = i[a/64] | 0x01<<(63-(a%64))
-	return i
-}
-
-// isBitSet returns true if bit number a is true in i. Count starts at 0.
-func isBitSet(i Int256, a uint8) bool {
-	return i[a/64]&(0x01<<(63-(a%64))) != 0
-}
-
-// BIT STUFF END ==========
-
-// Reason explains why the sliding window has made a decision.
-type Reason uint8
-
-const (
-	ReasonFirst Reason = iota
-	ReasonReuse
-	ReasonShift
-	ReasonOutOfWindow
-)
+	reason, ok := window.check(window.bitmap[:], nonce, offset)
+	switch {
+	case reason == ReasonReuse:
+		return ReasonReuse, window.acceptIdempotent
+	case reason == ReasonFirst && window.detectReorder && nonce < window.lastAccepted:
+		return ReasonReorder, true
+	}
+	return reason, ok
+}
+
+// Valid returns true if the nonce is valid, see CheckNonce. It does not change the state.
+func (window *SlidingWindow) Valid(nonce uint64) bool {
+	_, ok := window.CheckNonce(nonce)
+	return ok
+}
+
+// Accept returns true if the nonce is valid and records it, see CheckAndSetNonce.
+func (window *SlidingWindow) Accept(nonce uint64) bool {
+	_, ok := window.CheckAndSetNonce(nonce)
+	return ok
+}
+
+// DryRunShift returns the reason CheckNonce gives for nonce and the number of positions CheckAndSetNonce would shift
+// the window. It does not change the state.
+func (window *SlidingWindow) DryRunShift(nonce uint64) (Reason, uint64) {
+	reason, _ := window.CheckNonce(nonce)
+	if reason != ReasonShift {
+		return reason, 0
+	}
+	return reason, nonce - uint64(window.WindowSize()) + 1 - window.offset
+}
+
+// OutOfWindowDistance returns how far nonce lies below the window, Offset()-nonce, or 0 if it doesn't. It tells late
+// arrivals just below the window from replays of very old nonces. It does not change the state.
+func (window *SlidingWindow) OutOfWindowDistance(nonce uint64) uint64 {
+	if nonce >= window.offset {
+		return 0
+	}
+	return window.offset - nonce
+}
+
+// LastDecision returns the nonce, reason and result of the most recent call to CheckAndSetNonce, for debugging. Before
+// the first call it returns the zero values 0, ReasonFirst and false.
+func (window *SlidingWindow) LastDecision() (uint64, Reason, bool) {
+	return window.lastNonce, window.lastReason, window.lastOK
+}
+
+// WindowSize returns the number of nonces tracked by the window.
+func (window *SlidingWindow) WindowSize() int {
+	return int(window.windowSize(window.bitmap[:]))
+}
+
+// Offset returns the nonce represented by bit 0 of the bitmap. Nonces below it are out of window.
+func (window *SlidingWindow) Offset() uint64 {
+	return window.offset
+}
+
+// AcceptedRange returns the inclusive range of nonces the window tracks. Nonces below low are always rejected, nonces
+// above high always shift the window. High is capped at math.MaxUint64.
+func (window *SlidingWindow) AcceptedRange() (low, high uint64) {
+	high = window.offset + uint64(window.WindowSize()) - 1
+	if high < window.offset {
+		high = math.MaxUint64
+	}
+	return window.offset, high
+}
+
+// HighestNonce returns the largest nonce accepted within the window. If no nonce within the window has been accepted it
+// returns Offset()-1, which is math.MaxUint64 for a fresh window and the persisted high-water mark for a window created
+// by NewSlidingWindow(mark+1).
+func (window *SlidingWindow) HighestNonce() uint64 {
+	// The last set bit is at 255-TrailingZeros, an empty bitmap yields offset-1.
+	return window.offset + 255 - uint64(window.bitmap.TrailingZeros())
+}
 
-func (r Reason) String() string {
-	switch r {
-	case ReasonFirst:
-		return "First"
-	case ReasonReuse:
-		return "Reuse"
-	case ReasonShift:
-		return "Shift"
-	case ReasonOutOfWindow:
-		return "Small"
-	}
-	return "Unknown"
-}
-
-func main() {
-	nonces := argsToInt()
-	if len(nonces) == 0 {
-		fmt.Printf("Usage:\n$ %s <nonce> <nonce> <nonce>...\n\n", path.Base(os.Args[0]))
-		os.Exit(1)
-	}
-	window := new(SlidingWindow)
-	fmt.Println("\nApplying nonces in order:", nonces)
-	fmt.Println("Nonce\tOK?\tReason\tOffset\tBitmap")
-	fmt.Println(strings.Repeat("=", 288))
-	for _, nonce := range nonces {
-		reason, ok := window.CheckAndSetNonce(nonce)
-		fmt.Printf("%d\t%t\t%s\t%d\t%s\n", nonce, ok, reason, window.offset, printWindow(window, nonce))
-	}
-}
-
-// IGNORE BELOW: ======================================================================
-
-// convert arguments to uint64
-func argsToInt() []uint64 {
-	if len(os.Args) < 2 {
-		return nil
-	}
-	r := make([]uint64, len(os.Args)-1)
-	j := 0
-	for i := 1; i < len(os.Args); i++ {
-		x, err := strconv.ParseUint(os.Args[i], 10, 64)
-		if err != nil {
-			continue
+// IsHighestNonce returns true if nonce is the highest nonce accepted within the window. After CheckAndSetNonce returned
+// ReasonReuse it tells a retransmission of the latest nonce, e.g. by a stuck sender, from other duplicates.
+func (window *SlidingWindow) IsHighestNonce(nonce uint64) bool {
+	return !window.bitmap.IsZero() && window.HighestNonce() == nonce
+}
+
+// Bitmap returns a copy of the bitmap. Bit n is set if nonce Offset()+n has been seen.
+func (window *SlidingWindow) Bitmap() Int256 {
+	return window.bitmap
+}
+
+// Words returns the bitmap as plain words for interoperability, e.g. to map it to protobuf fields. Index 0 is the most
+// significant word and its most significant bit is nonce Offset(), index 3 holds the nonces up to Offset()+255.
+func (window *SlidingWindow) Words() [4]uint64 {
+	return window.bitmap
+}
+
+// SetWords sets the bitmap from plain words as returned by Words. Like SetState it does not validate the words.
+func (window *SlidingWindow) SetWords(words [4]uint64) {
+	window.bitmap = words
+}
+
+// SetState sets the offset and bitmap of the window, as returned by Offset and Bitmap. It trusts the caller and does
+// not validate the state, e.g. bits beyond the window size.
+func (window *SlidingWindow) SetState(offset uint64, bitmap Int256) {
+	window.offset = offset
+	window.bitmap = bitmap
+}
+
+// SetRejectZero makes the window reject nonce 0 with ReasonOutOfWindow, even if it is a fresh window, for protocols
+// that reserve sequence number 0. By default nonce 0 is valid. It must be called before the window is used.
+func (window *SlidingWindow) SetRejectZero(reject bool) {
+	window.rejectZero = reject
+}
+
+// SetDetectReorder makes CheckAndSetNonce return ReasonReorder instead of ReasonFirst for nonces that are accepted
+// within the window but are lower than the previous accepted nonce, to tell reordered traffic from traffic moving
+// forward. By default reordering is not reported. It must be called before the window is used.
+func (window *SlidingWindow) SetDetectReorder(detect bool) {
+	window.detectReorder = detect
+}
+
+// SetAcceptIdempotent makes CheckNonce and CheckAndSetNonce return true for nonces already seen within the window, for
+// at-least-once delivery where a retransmission should get the cached result instead of an error. The reason stays
+// ReasonReuse, so Reason.Err and Counters.ByReason still report it as a duplicate, while Counters.Stats counts it as
+// accepted. By default they return false. It must be called before the window is used.
+func (window *SlidingWindow) SetAcceptIdempotent(accept bool) {
+	window.acceptIdempotent = accept
+}
+
+// SetResetThreshold makes the window treat threshold consecutive nonces below the window as a restart of the sender,
+// whose counter dropped to a low value. The window is then reset to the smallest of these nonces and the last one is
+// checked again, so it gets accepted. Any nonce that is not below the window ends the streak, so sporadic late
+// nonces don't trigger a reset. Nonce 0 of a window with SetRejectZero is ignored. A threshold of 0, the default,
+// disables resets. It must be called before the window is used.
+//
+// Warning: this weakens replay protection. Anyone who can replay threshold captured old messages in a row forces a
+// reset and can then replay every old message from the smallest of them on. Only enable it if a restarted sender is
+// otherwise detected, e.g. because messages are authenticated with a key renewed on restart.
+func (window *SlidingWindow) SetResetThreshold(threshold int) {
+	window.resetThreshold = threshold
+}
+
+// SetCounters makes CheckAndSetNonce count its decisions in counters, nil disables counting. Counters can be shared
+// by several windows to aggregate their decisions. It must be called before the window is used.
+func (window *SlidingWindow) SetCounters(counters *Counters) {
+	window.counters = counters
+}
+
+// SetOnShift sets a function that CheckAndSetNonce calls after shifting the window, nil removes it. Nonces below
+// newOffset are rejected from then on. It is not called for nonces within or below the window. The function runs
+// synchronously, under any lock held by a concurrent wrapper like ConcurrentSlidingWindow, and must not use the window.
+func (window *SlidingWindow) SetOnShift(fn func(oldOffset, newOffset uint64)) {
+	window.onShift = fn
+}
+
+// ShiftBy advances the window by delta positions, e.g. to skip a range of known-bad nonces. This discards the acceptance
+// history of the skipped range, a delta of at least the window size clears the bitmap. The offset is capped at
+// math.MaxUint64.
+func (window *SlidingWindow) ShiftBy(delta uint64) {
+	newOffset := window.offset + delta
+	if newOffset < window.offset {
+		newOffset = math.MaxUint64
+	}
+	window.bitmap = shiftLeft(window.bitmap, newOffset-window.offset)
+	window.offset = newOffset
+}
+
+// Reset clears the window, returning it to the state of new(SlidingWindow) while keeping the configuration, e.g. the
+// window size. This allows reusing windows, e.g. from a sync.Pool.
+func (window *SlidingWindow) Reset() {
+	window.ResetTo(0)
+}
+
+// ResetTo clears the window and moves it to offset, e.g. when rekeying a session. Unlike ShiftBy it discards all
+// history unconditionally, and offset may also be lower than the current one. The configuration is kept.
+func (window *SlidingWindow) ResetTo(offset uint64) {
+	window.offset = offset
+	window.bitmap = Int256{}
+	window.lastAccepted = 0
+	window.lastNonce, window.lastReason, window.lastOK = 0, ReasonFirst, false
+	window.lowStreak = 0
+}
+
+// Verify checks that the window state could have resulted from valid operations, e.g. after decoding it from untrusted
+// storage. It returns an error wrapping ErrInvalidState if bits beyond the window size are set, or bits for nonces
+// beyond math.MaxUint64. An empty bitmap is valid for any offset, as created by NewSlidingWindow or ResetTo.
+func (window *SlidingWindow) Verify() error {
+	if !window.bitmap.And(window.mask().Not()).IsZero() {
+		return fmt.Errorf("%w: bits set beyond window size %d", ErrInvalidState, window.WindowSize())
+	}
+	if highest := MaxWindowSize - 1 - window.bitmap.TrailingZeros(); highest >= 0 &&
+		window.offset > math.MaxUint64-uint64(highest) {
+		return fmt.Errorf("%w: bit %d at offset %d is beyond the largest nonce", ErrInvalidState, highest, window.offset)
+	}
+	return nil
+}
+
+// Clone returns an independent copy of the window. Changes to the copy do not affect the original, but decisions of both
+// are counted in the same Counters, if any.
+func (window *SlidingWindow) Clone() *SlidingWindow {
+	clone := *window
+	return &clone
+}
+
+// Equal returns true if both windows have the same offset and bitmap. Two nil windows are equal, a nil window is not
+// equal to a non-nil one.
+func (window *SlidingWindow) Equal(other *SlidingWindow) bool {
+	if window == nil || other == nil {
+		return window == other
+	}
+	return window.offset == other.offset && window.bitmap.Equal(other.bitmap)
+}
+
+// SeenCount returns the number of nonces accepted within the current window.
+func (window *SlidingWindow) SeenCount() int {
+	return window.bitmap.PopCount()
+}
+
+// ClearNonce forgets that nonce has been accepted so it becomes valid again, e.g. when the message carrying it has
+// been rejected later on. It returns false if nonce is outside of the window.
+func (window *SlidingWindow) ClearNonce(nonce uint64) bool {
+	if nonce < window.offset || nonce-window.offset >= uint64(window.WindowSize()) {
+		return false
+	}
+	window.bitmap = clearBit(window.bitmap, uint8(nonce-window.offset))
+	return true
+}
+
+// Merge adds the nonces accepted by other to the window. The merged window starts at the larger of both offsets, nonces
+// that fall out of it are dropped. The window size of the receiver is kept. If it is smaller than that of other, the
+// window moves further so that the highest nonce accepted by other stays within it and is not accepted again.
+func (window *SlidingWindow) Merge(other *SlidingWindow) {
+	offset := max(window.offset, other.offset)
+	if !other.bitmap.IsZero() {
+		highest, size := other.HighestNonce(), uint64(window.WindowSize())
+		if highest >= offset && highest-offset >= size {
+			offset = highest - size + 1
 		}
-		r[j] = x
-		j++
 	}
-	return r
+	window.ShiftBy(offset - window.offset)
+	window.orAligned(other.offset, other.bitmap)
 }
 
-func blurString(s string, bitPos int) string {
-	var one, zero, red, end = []byte("\u001B[0;37m"), []byte("\u001B[1;30m"), []byte("\033[0;31m"), []byte("\033[0m")
-	var last byte
-	color := func(b byte) []byte {
-		switch b {
-		case '1':
-			return one
-		case '0':
-			return zero
+// orAligned adds the nonces set in the bitmap other of a window starting at otherOffset to the window, without moving
+// it. Nonces outside of the window are dropped.
+func (window *SlidingWindow) orAligned(otherOffset uint64, other Int256) {
+	if otherOffset > window.offset {
+		other = shiftRight(other, otherOffset-window.offset)
+	} else {
+		other = shiftLeft(other, window.offset-otherOffset)
+	}
+	window.bitmap = window.bitmap.Or(other.And(window.mask()))
+}
+
+// Difference returns the nonces accepted by the window but not by other, in ascending order, e.g. to diagnose replicas
+// drifting apart. Only the range covered by both windows is compared, nonces outside of the other window are excluded.
+func (window *SlidingWindow) Difference(other *SlidingWindow) []uint64 {
+	otherBitmap, otherMask := other.bitmap, other.mask()
+	if other.offset > window.offset {
+		otherBitmap = shiftRight(otherBitmap, other.offset-window.offset)
+		otherMask = shiftRight(otherMask, other.offset-window.offset)
+	} else {
+		otherBitmap = shiftLeft(otherBitmap, window.offset-other.offset)
+		otherMask = shiftLeft(otherMask, window.offset-other.offset)
+	}
+	diff := window.bitmap.And(otherBitmap.Not()).And(otherMask)
+	var nonces []uint64
+	for pos := 0; pos < MaxWindowSize; pos++ {
+		if diff.IsSet(uint8(pos)) {
+			nonces = append(nonces, window.offset+uint64(pos))
 		}
-		return []byte{}
-	}
-	a := make([]byte, 0, len(s)+4)
-	last = s[0]
-	a = append(a, color(last)...)
-	for p, b := range []byte(s) {
-		if p == bitPos {
-			a = append(a, end...)
-			a = append(a, red...)
-			a = append(a, b)
-			a = append(a, end...)
-			last = 0x00
-			continue
+	}
+	return nonces
+}
+
+// Hash returns a 64-bit FNV-1a hash of the offset and bitmap, e.g. to detect diverging replicas cheaply. Windows that
+// are Equal have the same hash. It is not a cryptographic hash and must not be relied on against malicious input.
+func (window *SlidingWindow) Hash() uint64 {
+	var b [binaryLength]byte
+	window.putBinary(b[:])
+	h := fnv.New64a()
+	h.Write(b[:])
+	return h.Sum64()
+}
+
+// mask returns an Int256 with the bits covered by the window size set.
+func (window *SlidingWindow) mask() Int256 {
+	return setBitRange(Int256{}, 0, uint8(window.WindowSize()-1))
+}
+
+// String returns a compact representation of the window like "offset=12345 bitmap=0x...", with the bitmap as 64 hex
+// digits of words 0 to 3.
+func (window *SlidingWindow) String() string {
+	return fmt.Sprintf("offset=%d bitmap=0x%x", window.offset, window.bitmap)
+}
+
+// Accepted returns the nonces accepted within the window in ascending order.
+func (window *SlidingWindow) Accepted() []uint64 {
+	accepted := make([]uint64, 0, window.SeenCount())
+	for pos := 0; pos < MaxWindowSize; pos++ {
+		if isBitSet(window.bitmap, uint8(pos)) {
+			accepted = append(accepted, window.offset+uint64(pos))
 		}
-		if b != last {
-			a = append(a, end...)
-			a = append(a, color(b)...)
-			last = b
+	}
+	return accepted
+}
+
+// MissingNonces returns the nonces within the window below the highest accepted nonce that have not been accepted, in
+// ascending order. Nonces above the highest accepted one have not been due yet and are not reported.
+func (window *SlidingWindow) MissingNonces() []uint64 {
+	var missing []uint64
+	highest := MaxWindowSize - 1 - window.bitmap.TrailingZeros()
+	for pos := 0; pos < highest; pos++ {
+		if !isBitSet(window.bitmap, uint8(pos)) {
+			missing = append(missing, window.offset+uint64(pos))
+		}
+	}
+	return missing
+}
+
+// Density returns the fraction of the window size that has been accepted, between 0.0 and 1.0.
+func (window *SlidingWindow) Density() float64 {
+	return float64(window.SeenCount()) / float64(window.WindowSize())
+}
+
+// HighestContiguous returns the largest nonce N such that all nonces from Offset() to N have been accepted, like a
+// cumulative acknowledgment. Nonces below Offset() can't be accepted anymore and are considered settled. It returns
+// false if the nonce at Offset() has not been accepted.
+func (window *SlidingWindow) HighestContiguous() (uint64, bool) {
+	ones := window.bitmap.Not().LeadingZeros()
+	if ones == 0 {
+		return 0, false
+	}
+	return window.offset + uint64(ones) - 1, true
+}
+
+// WindowStats describes the state of a SlidingWindow, see the methods of the same names.
+type WindowStats struct {
+	Offset       uint64  `json:"offset"`
+	HighestNonce uint64  `json:"highest_nonce"`
+	SeenCount    int     `json:"seen_count"`
+	Density      float64 `json:"density"`
+}
+
+// Stats returns Offset, HighestNonce, SeenCount and Density in a single pass over the bitmap.
+func (window *SlidingWindow) Stats() WindowStats {
+	stats := WindowStats{
+		Offset:       window.offset,
+		HighestNonce: window.offset - 1,
+	}
+	for w, word := range window.bitmap {
+		if word != 0 {
+			stats.SeenCount += bits.OnesCount64(word)
+			stats.HighestNonce = window.offset + uint64(w*64+63-bits.TrailingZeros64(word))
 		}
-		a = append(a, b)
 	}
-	a = append(a, end...)
-	return string(a)
+	stats.Density = float64(stats.SeenCount) / float64(window.WindowSize())
+	return stats
 }
 
-// print state of the window, highlighting the bit to be tested/set
-func printWindow(window *SlidingWindow, nonce uint64) string {
-	if nonce < window.offset {
-		return blurString(fmt.Sprintf("%.64b%.64b%.64b%.64b", window.bitmap[0], window.bitmap[1], window.bitmap[2], window.bitmap[3]), math.MaxInt)
+// Snapshot is a checkpoint of the state of a SlidingWindow, see SlidingWindow.Snapshot.
+type Snapshot struct {
+	offset       uint64
+	bitmap       Int256
+	lastAccepted uint64
+	lowStreak    int
+	lowStreakMin uint64
+}
+
+// Snapshot returns a checkpoint of the window state that Restore can return to. Unlike Clone the configuration is not
+// copied, and restoring keeps the identity of the window.
+func (window *SlidingWindow) Snapshot() Snapshot {
+	return Snapshot{
+		offset:       window.offset,
+		bitmap:       window.bitmap,
+		lastAccepted: window.lastAccepted,
+		lowStreak:    window.lowStreak,
+		lowStreakMin: window.lowStreakMin,
 	}
-	return blurString(fmt.Sprintf("%.64b%.64b%.64b%.64b", window.bitmap[0], window.bitmap[1], window.bitmap[2], window.bitmap[3]), int(nonce-window.offset))
+}
+
+// Restore returns the window to the state of a Snapshot, discarding all changes since.
+func (window *SlidingWindow) Restore(s Snapshot) {
+	window.offset = s.offset
+	window.bitmap = s.bitmap
+	window.lastAccepted = s.lastAccepted
+	window.lowStreak = s.lowStreak
+	window.lowStreakMin = s.lowStreakMin
 }
@@ -0,0 +1,493 @@
+package slidingwindow
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// step is a nonce together with the expected decision of CheckAndSetNonce.
+type step struct {
+	nonce  uint64
+	reason Reason
+	ok     bool
+}
+
+// checkSteps applies steps to window in order and reports unexpected decisions.
+func checkSteps(t *testing.T, window *SlidingWindow, steps []step) {
+	t.Helper()
+	for _, s := range steps {
+		if reason, ok := window.CheckAndSetNonce(s.nonce); reason != s.reason || ok != s.ok {
+			t.Errorf("CheckAndSetNonce(%d) = %s, %t, want %s, %t", s.nonce, reason, ok, s.reason, s.ok)
+		}
+	}
+}
+
+func TestCheckAndSetNonceTopOfRange(t *testing.T) {
+	window := new(SlidingWindow)
+	checkSteps(t, window, []step{
+		{math.MaxUint64, ReasonShift, true},
+		{math.MaxUint64 - 255, ReasonFirst, true},
+		{math.MaxUint64 - 256, ReasonOutOfWindow, false},
+		{math.MaxUint64, ReasonReuse, false},
+	})
+	if offset := window.Offset(); offset != math.MaxUint64-255 {
+		t.Errorf("Offset() = %d, want %d", offset, uint64(math.MaxUint64-255))
+	}
+
+	// offset+windowSize overflows, the top nonces must still be within the window.
+	window = NewSlidingWindow(math.MaxUint64 - 255)
+	checkSteps(t, window, []step{
+		{math.MaxUint64, ReasonFirst, true},
+		{math.MaxUint64 - 255, ReasonFirst, true},
+		{math.MaxUint64 - 256, ReasonOutOfWindow, false},
+		{math.MaxUint64, ReasonReuse, false},
+	})
+	if offset := window.Offset(); offset != math.MaxUint64-255 {
+		t.Errorf("Offset() = %d, want %d", offset, uint64(math.MaxUint64-255))
+	}
+}
+
+func TestClearNonce(t *testing.T) {
+	window := NewSlidingWindow(100)
+	checkSteps(t, window, []step{
+		{105, ReasonFirst, true},
+		{105, ReasonReuse, false},
+	})
+	if !window.ClearNonce(105) {
+		t.Fatal("ClearNonce(105) = false, want true")
+	}
+	checkSteps(t, window, []step{
+		{105, ReasonFirst, true},
+		{105, ReasonReuse, false},
+	})
+	for _, nonce := range []uint64{99, 356} {
+		if window.ClearNonce(nonce) {
+			t.Errorf("ClearNonce(%d) = true for a nonce out of window", nonce)
+		}
+	}
+}
+
+func TestRFC6479Window(t *testing.T) {
+	// RFC 6479 rejects 0 and accepts a sequence number if it is larger than the highest one, or not below
+	// highest-windowSize and not seen yet.
+	window, err := NewRFC6479Window(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSteps(t, window, []step{
+		{0, ReasonOutOfWindow, false},
+		{1, ReasonFirst, true},
+		{1, ReasonReuse, false},
+		{33, ReasonShift, true}, // highest 33, window 1 to 33
+		{1, ReasonReuse, false},
+		{2, ReasonFirst, true},
+		{40, ReasonShift, true}, // highest 40, window 8 to 40
+		{8, ReasonFirst, true},  // leftmost edge highest-windowSize
+		{7, ReasonOutOfWindow, false},
+		{40, ReasonReuse, false},
+		{39, ReasonFirst, true},
+		{1000, ReasonShift, true}, // jump, everything before is dropped
+		{968, ReasonFirst, true},
+		{967, ReasonOutOfWindow, false},
+		{999, ReasonFirst, true},
+		{999, ReasonReuse, false},
+	})
+	for _, size := range []int{0, MaxWindowSize} {
+		if _, err := NewRFC6479Window(size); err != ErrUnsupportedWindowSize {
+			t.Errorf("NewRFC6479Window(%d) error = %v, want %v", size, err, ErrUnsupportedWindowSize)
+		}
+	}
+}
+
+func FuzzCheckAndSetNonce(f *testing.F) {
+	// The input is a sequence of big-endian nonces, a trailing partial nonce is ignored.
+	seed := func(nonces ...uint64) []byte {
+		var b []byte
+		for _, nonce := range nonces {
+			b = binary.BigEndian.AppendUint64(b, nonce)
+		}
+		return b
+	}
+	f.Add(seed(0))
+	f.Add(seed(255))
+	f.Add(seed(256))
+	f.Add(seed(math.MaxUint64))
+	f.Add(seed(0, 255, 256, 0, 255, math.MaxUint64, 0, math.MaxUint64))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		window := new(SlidingWindow)
+		accepted := make(map[uint64]bool)
+		for ; len(data) >= 8; data = data[8:] {
+			nonce := binary.BigEndian.Uint64(data)
+			offset := window.Offset()
+			checkReason, checkOK := window.CheckNonce(nonce)
+			reason, ok := window.CheckAndSetNonce(nonce)
+			if checkReason != reason || checkOK != ok {
+				t.Fatalf("CheckNonce(%d) = %s, %t, but CheckAndSetNonce = %s, %t", nonce, checkReason, checkOK, reason, ok)
+			}
+			if ok && accepted[nonce] {
+				t.Fatalf("nonce %d accepted twice", nonce)
+			}
+			accepted[nonce] = accepted[nonce] || ok
+			if window.Offset() < offset {
+				t.Fatalf("offset decreased from %d to %d", offset, window.Offset())
+			}
+		}
+	})
+}
+
+func BenchmarkCheckAndSetNonce(b *testing.B) {
+	b.Run("First", func(b *testing.B) {
+		window := new(SlidingWindow)
+		for i := 0; i < b.N; i++ {
+			if i%DefaultWindowSize == 0 {
+				window.Reset()
+			}
+			window.CheckAndSetNonce(uint64(i % DefaultWindowSize))
+		}
+	})
+	b.Run("Reuse", func(b *testing.B) {
+		window := new(SlidingWindow)
+		window.CheckAndSetNonce(100)
+		for i := 0; i < b.N; i++ {
+			window.CheckAndSetNonce(100)
+		}
+	})
+	// Consecutive nonces shift the window by one, as for traffic in order.
+	b.Run("Shift", func(b *testing.B) {
+		window := new(SlidingWindow)
+		for i := 0; i < b.N; i++ {
+			window.CheckAndSetNonce(uint64(DefaultWindowSize + i))
+		}
+	})
+	// Bursts skip nonces and shift by more than a word.
+	b.Run("ShiftBurst", func(b *testing.B) {
+		window := new(SlidingWindow)
+		for i := 0; i < b.N; i++ {
+			window.CheckAndSetNonce(uint64(DefaultWindowSize + i*100))
+		}
+	})
+}
+
+func TestRightEdge(t *testing.T) {
+	for _, size := range []int{1, 64, 100, MaxWindowSize} {
+		window, err := NewSlidingWindowSize(1000, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last := uint64(1000 + size - 1)
+		for _, s := range []step{
+			{last, ReasonFirst, true},
+			{last + 1, ReasonShift, true},
+		} {
+			if reason, ok := window.CheckNonce(s.nonce); reason != s.reason || ok != s.ok {
+				t.Errorf("size %d: CheckNonce(%d) = %s, %t, want %s, %t", size, s.nonce, reason, ok, s.reason, s.ok)
+			}
+			checkSteps(t, window, []step{s})
+		}
+		if offset := window.Offset(); offset != 1001 {
+			t.Errorf("size %d: Offset() = %d after shifting by one, want 1001", size, offset)
+		}
+	}
+}
+
+func TestCheckNonceAgrees(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		window, err := NewSlidingWindowSize(0, 1+rng.Intn(MaxWindowSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		window.SetRejectZero(rng.Intn(2) == 0)
+		window.SetDetectReorder(rng.Intn(2) == 0)
+		offset := rng.Uint64() >> rng.Intn(64)
+		bitmap := Int256{rng.Uint64(), rng.Uint64(), rng.Uint64(), rng.Uint64()}
+		window.SetState(offset, bitmap.And(window.mask()))
+		window.lastAccepted = offset + uint64(rng.Intn(MaxWindowSize))
+		// Nonces around the window hit all branches, some wrap around at the ends of the range.
+		nonce := offset + uint64(rng.Intn(3*MaxWindowSize)) - MaxWindowSize
+		checkReason, checkOK := window.CheckNonce(nonce)
+		if reason, ok := window.CheckAndSetNonce(nonce); checkReason != reason || checkOK != ok {
+			t.Fatalf("offset %d bitmap %x nonce %d: CheckNonce = %s, %t, but CheckAndSetNonce = %s, %t",
+				offset, bitmap, nonce, checkReason, checkOK, reason, ok)
+		}
+	}
+}
+
+func TestRejectZero(t *testing.T) {
+	// By default nonce 0 is accepted on a fresh window.
+	checkSteps(t, new(SlidingWindow), []step{
+		{0, ReasonFirst, true},
+		{0, ReasonReuse, false},
+	})
+
+	set := new(SlidingWindow)
+	set.SetRejectZero(true)
+	option, err := New(WithRejectZero())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, window := range map[string]*SlidingWindow{"SetRejectZero": set, "WithRejectZero": option} {
+		if reason, ok := window.CheckNonce(0); reason != ReasonOutOfWindow || ok {
+			t.Errorf("%s: CheckNonce(0) = %s, %t, want %s, false", name, reason, ok, ReasonOutOfWindow)
+		}
+		checkSteps(t, window, []step{
+			{0, ReasonOutOfWindow, false},
+			{1, ReasonFirst, true},
+			{0, ReasonOutOfWindow, false},
+		})
+		if count := window.SeenCount(); count != 1 {
+			t.Errorf("%s: SeenCount() = %d, want 1", name, count)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name         string
+		size         int
+		nonces       []uint64
+		otherSize    int
+		otherOffset  uint64
+		otherNonces  []uint64
+		wantOffset   uint64
+		wantAccepted []uint64
+	}{
+		{"same size", 256, []uint64{1000, 1100}, 256, 1050, []uint64{1050, 1200}, 1050, []uint64{1050, 1100, 1200}},
+		// The smaller receiver moves so that the highest nonce of other stays within it.
+		{"smaller receiver", 64, []uint64{1010}, 256, 1000, []uint64{1200}, 1137, []uint64{1200}},
+		{"smaller receiver fits", 64, []uint64{1010}, 256, 1000, []uint64{1050, 1063}, 1000, []uint64{1010, 1050, 1063}},
+		{"larger receiver", 256, []uint64{1000}, 64, 900, []uint64{950}, 1000, []uint64{1000}},
+		{"empty other", 256, []uint64{1000}, 256, 2000, nil, 2000, nil},
+	}
+	for _, test := range tests {
+		window, err := NewSlidingWindowSize(1000, test.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, nonce := range test.nonces {
+			window.CheckAndSetNonce(nonce)
+		}
+		other, err := NewSlidingWindowSize(test.otherOffset, test.otherSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, nonce := range test.otherNonces {
+			other.CheckAndSetNonce(nonce)
+		}
+		window.Merge(other)
+		if offset := window.Offset(); offset != test.wantOffset {
+			t.Errorf("%s: Offset() = %d, want %d", test.name, offset, test.wantOffset)
+		}
+		if got := window.Accepted(); !slices.Equal(got, test.wantAccepted) {
+			t.Errorf("%s: Accepted() = %v, want %v", test.name, got, test.wantAccepted)
+		}
+		// No nonce accepted by either window is accepted again.
+		for _, nonce := range append(test.nonces, test.otherNonces...) {
+			if reason, ok := window.CheckNonce(nonce); ok {
+				t.Errorf("%s: CheckNonce(%d) = %s, %t after Merge", test.name, nonce, reason, ok)
+			}
+		}
+	}
+}
+
+func TestOrAligned(t *testing.T) {
+	tests := []struct {
+		name        string
+		size        int
+		otherOffset uint64
+		other       []uint8 // set bits of other
+		want        []uint64
+	}{
+		{"zero delta", 256, 100, []uint8{0, 10, 255}, []uint64{100, 105, 110, 355}},
+		// Bits move towards the end of the window, those beyond it are dropped.
+		{"positive delta", 256, 110, []uint8{0, 245, 246}, []uint64{105, 110, 355}},
+		// Bits move towards the start of the window, those below it are dropped.
+		{"negative delta", 256, 90, []uint8{0, 9, 10, 20, 255}, []uint64{100, 105, 110, 345}},
+		{"beyond window size", 64, 100, []uint8{0, 63, 64}, []uint64{100, 105, 163}},
+		{"delta beyond window", 256, 400, []uint8{0}, []uint64{105}},
+	}
+	for _, test := range tests {
+		window, err := NewSlidingWindowSize(100, test.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		window.CheckAndSetNonce(105)
+		var other Int256
+		for _, pos := range test.other {
+			other = setBit(other, pos)
+		}
+		window.orAligned(test.otherOffset, other)
+		if got := window.Accepted(); !slices.Equal(got, test.want) {
+			t.Errorf("%s: Accepted() = %v, want %v", test.name, got, test.want)
+		}
+		if offset := window.Offset(); offset != 100 {
+			t.Errorf("%s: Offset() = %d, want 100", test.name, offset)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	window, err := NewSlidingWindowSize(1000, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSteps(t, window, []step{
+		{1000, ReasonFirst, true},
+		{5000, ReasonShift, true},
+	})
+	window.Reset()
+	if offset, count := window.Offset(), window.SeenCount(); offset != 0 || count != 0 {
+		t.Errorf("Reset(): Offset() = %d, SeenCount() = %d, want 0, 0", offset, count)
+	}
+	if size := window.WindowSize(); size != 100 {
+		t.Errorf("Reset(): WindowSize() = %d, want 100", size)
+	}
+	checkSteps(t, window, []step{
+		{0, ReasonFirst, true},
+		{0, ReasonReuse, false},
+		{99, ReasonFirst, true},
+		{100, ReasonShift, true},
+	})
+}
+
+func TestResetTo(t *testing.T) {
+	window := new(SlidingWindow)
+	checkSteps(t, window, []step{
+		{5000, ReasonShift, true},
+		{4990, ReasonFirst, true},
+	})
+	for _, n := range []uint64{100, 4990, 10000} {
+		window.ResetTo(n)
+		if offset, count := window.Offset(), window.SeenCount(); offset != n || count != 0 {
+			t.Errorf("ResetTo(%d): Offset() = %d, SeenCount() = %d", n, offset, count)
+		}
+		checkSteps(t, window, []step{
+			{n, ReasonFirst, true},
+			{n - 1, ReasonOutOfWindow, false},
+		})
+	}
+}
+
+func TestResetThreshold(t *testing.T) {
+	// Sporadic stragglers below the window don't reset it.
+	window, err := New(WithResetThreshold(3), WithStartOffset(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSteps(t, window, []step{
+		{5, ReasonOutOfWindow, false},
+		{6, ReasonOutOfWindow, false},
+		{1000, ReasonFirst, true},
+		{7, ReasonOutOfWindow, false},
+		{8, ReasonOutOfWindow, false},
+		{1001, ReasonFirst, true},
+	})
+	if offset := window.Offset(); offset != 1000 {
+		t.Errorf("Offset() = %d after stragglers, want 1000", offset)
+	}
+
+	// A restarted sender resets the window to the smallest nonce of the streak.
+	checkSteps(t, window, []step{
+		{7, ReasonOutOfWindow, false},
+		{5, ReasonOutOfWindow, false},
+		{6, ReasonFirst, true},
+		{7, ReasonFirst, true},
+		{6, ReasonReuse, false},
+		{4, ReasonOutOfWindow, false},
+	})
+	if offset := window.Offset(); offset != 5 {
+		t.Errorf("Offset() = %d after reset, want 5", offset)
+	}
+}
+
+func TestResetThresholdRejectZero(t *testing.T) {
+	// The reserved nonce 0 neither counts towards the streak nor ends it.
+	window, err := New(WithResetThreshold(2), WithStartOffset(1000), WithRejectZero())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSteps(t, window, []step{
+		{5, ReasonOutOfWindow, false},
+		{0, ReasonOutOfWindow, false},
+		{0, ReasonOutOfWindow, false},
+	})
+	if offset := window.Offset(); offset != 1000 {
+		t.Errorf("Offset() = %d after nonce 0, want 1000", offset)
+	}
+	checkSteps(t, window, []step{
+		{6, ReasonFirst, true},
+		{0, ReasonOutOfWindow, false},
+	})
+	if offset := window.Offset(); offset != 5 {
+		t.Errorf("Offset() = %d after reset, want 5", offset)
+	}
+}
+
+func TestResetThresholdRestore(t *testing.T) {
+	window, err := New(WithResetThreshold(2), WithStartOffset(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := window.Snapshot()
+	checkSteps(t, window, []step{{5, ReasonOutOfWindow, false}})
+	// Restore discards the streak, a single nonce below the window doesn't reset it.
+	window.Restore(snapshot)
+	checkSteps(t, window, []step{{6, ReasonOutOfWindow, false}})
+	if offset := window.Offset(); offset != 1000 {
+		t.Errorf("Offset() = %d, want 1000", offset)
+	}
+}
+
+func TestCheckAndSetNonceShiftReset(t *testing.T) {
+	window, err := New(WithResetThreshold(2), WithStartOffset(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		nonce uint64
+		shift uint64
+	}{
+		{5, 0},
+		{6, 0}, // resets the window backwards to 5
+		{300, 40},
+		{300, 0},
+	}
+	for _, test := range tests {
+		if _, shift, _ := window.CheckAndSetNonceShift(test.nonce); shift != test.shift {
+			t.Errorf("CheckAndSetNonceShift(%d) shift = %d, want %d", test.nonce, shift, test.shift)
+		}
+	}
+}
+
+func TestBitOrderings(t *testing.T) {
+	// The same seen nonces recorded in both orderings lead to the same decisions once converted with ReverseBits.
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var be, le Int256
+		for n := 0; n < 64; n++ {
+			pos := uint8(rng.Intn(MaxWindowSize))
+			be, le = be.SetBit(pos), le.SetBitLE(pos)
+		}
+		for pos := 0; pos < MaxWindowSize; pos++ {
+			if be.IsSet(uint8(pos)) != le.IsSetLE(uint8(pos)) {
+				t.Fatalf("bit %d: IsSet = %t, IsSetLE = %t", pos, be.IsSet(uint8(pos)), le.IsSetLE(uint8(pos)))
+			}
+		}
+		window, external := NewSlidingWindow(1000), NewSlidingWindow(1000)
+		window.SetState(1000, be)
+		external.SetState(1000, le.ReverseBits())
+		for nonce := uint64(990); nonce < 1000+MaxWindowSize+10; nonce++ {
+			reason, ok := window.CheckNonce(nonce)
+			if externalReason, externalOK := external.CheckNonce(nonce); reason != externalReason || ok != externalOK {
+				t.Fatalf("CheckNonce(%d) = %s, %t for big-endian bits but %s, %t for little-endian bits",
+					nonce, reason, ok, externalReason, externalOK)
+			}
+		}
+		if back := window.Bitmap().ReverseBits(); back != le {
+			t.Fatalf("Bitmap().ReverseBits() = %x, want %x", back, le)
+		}
+	}
+}
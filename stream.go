@@ -0,0 +1,37 @@
+package slidingwindow
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FilterStream reads newline-delimited decimal nonces from r, applies them in order to a new SlidingWindow and writes
+// the accepted ones to w, one per line. Empty lines are ignored, malformed lines are skipped and counted in skipped.
+func FilterStream(r io.Reader, w io.Writer) (skipped int, err error) {
+	window := new(SlidingWindow)
+	scanner := bufio.NewScanner(r)
+	out := bufio.NewWriter(w)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		nonce, err := strconv.ParseUint(line, 10, 64)
+		if err != nil {
+			skipped++
+			continue
+		}
+		if _, ok := window.CheckAndSetNonce(nonce); !ok {
+			continue
+		}
+		if _, err := out.WriteString(strconv.FormatUint(nonce, 10) + "\n"); err != nil {
+			return skipped, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return skipped, err
+	}
+	return skipped, out.Flush()
+}
@@ -0,0 +1,95 @@
+package slidingwindow
+
+// WideWindow implements the sliding window algorithm of SlidingWindow for windows larger than MaxWindowSize, e.g. 512
+// or 1024 nonces. It shares its implementation with SlidingWindow and Window but its bitmap is a slice allocated at
+// runtime, using the same big-endian bit layout as Int256, which makes it slower than SlidingWindow. The zero value is
+// an empty window of DefaultWindowSize starting at offset 0, like that of SlidingWindow, use NewWideWindow for larger
+// windows. It is not synchronized.
+type WideWindow struct {
+	core            // offset and size, where size 0 means all bits of the bitmap
+	bitmap []uint64 // allocated on first use for the zero value
+}
+
+// NewWideWindow returns an empty WideWindow starting at startOffset that tracks size nonces, covering startOffset to
+// startOffset+size-1. It returns ErrUnsupportedWindowSize if size is less than 1.
+func NewWideWindow(startOffset uint64, size int) (*WideWindow, error) {
+	if size < 1 {
+		return nil, ErrUnsupportedWindowSize
+	}
+	return &WideWindow{
+		core: core{
+			offset: startOffset,
+			size:   uint64(size),
+		},
+		bitmap: make([]uint64, (size+63)/64),
+	}, nil
+}
+
+// CheckAndSetNonce returns true if the nonce is valid, false otherwise. It updates the WideWindow to prevent the nonce
+// from being valid in the future.
+func (window *WideWindow) CheckAndSetNonce(nonce uint64) (Reason, bool) {
+	window.init()
+	reason, ok := window.check(window.bitmap, nonce, window.offset)
+	if ok {
+		window.set(window.bitmap, nonce)
+	}
+	return reason, ok
+}
+
+// CheckNonce returns true if the nonce is valid. It does not change the state.
+func (window *WideWindow) CheckNonce(nonce uint64) (Reason, bool) {
+	window.init()
+	return window.check(window.bitmap, nonce, window.offset)
+}
+
+// WindowSize returns the number of nonces tracked by the window.
+func (window *WideWindow) WindowSize() int {
+	window.init()
+	return int(window.windowSize(window.bitmap))
+}
+
+// Offset returns the nonce represented by bit 0 of the bitmap. Nonces below it are out of window.
+func (window *WideWindow) Offset() uint64 {
+	return window.offset
+}
+
+// init allocates the bitmap of the zero value.
+func (window *WideWindow) init() {
+	if window.bitmap == nil {
+		window.bitmap = make([]uint64, DefaultWindowSize/64)
+	}
+}
+
+// shiftLeftWords bit-shifts the bitmap words w by a bits to the left, in place. Word 0 is the most significant word.
+func shiftLeftWords(w []uint64, a uint64) {
+	n := uint64(len(w))
+	words := a / 64
+	if words >= n {
+		clear(w)
+		return
+	}
+	// shift full words
+	if words > 0 {
+		copy(w, w[words:])
+		clear(w[n-words:])
+	}
+	// shift remaining bits, nothing to merge for whole word shifts
+	b := a % 64
+	if b == 0 {
+		return
+	}
+	for i := uint64(0); i < n-1; i++ {
+		w[i] = (w[i] << b) | (w[i+1] >> (64 - b))
+	}
+	w[n-1] = w[n-1] << b
+}
+
+// setBitWords sets bit number a in the bitmap words w. Count starts at 0.
+func setBitWords(w []uint64, a uint64) {
+	w[a/64] = w[a/64] | 0x01<<(63-(a%64))
+}
+
+// isBitSetWords returns true if bit number a is true in the bitmap words w. Count starts at 0.
+func isBitSetWords(w []uint64, a uint64) bool {
+	return w[a/64]&(0x01<<(63-(a%64))) != 0
+}
@@ -0,0 +1,23 @@
+package slidingwindow
+
+import (
+	"testing"
+)
+
+func TestWideWindowZeroValue(t *testing.T) {
+	var wide WideWindow
+	var window SlidingWindow
+	if size := wide.WindowSize(); size != DefaultWindowSize {
+		t.Errorf("WindowSize() = %d, want %d", size, DefaultWindowSize)
+	}
+	for _, nonce := range []uint64{5, 5, 300, 44, 45, 299, 1000, 744, 745} {
+		reason, ok := wide.CheckNonce(nonce)
+		if wantReason, wantOK := window.CheckNonce(nonce); reason != wantReason || ok != wantOK {
+			t.Errorf("CheckNonce(%d) = %s, %t, want %s, %t", nonce, reason, ok, wantReason, wantOK)
+		}
+		reason, ok = wide.CheckAndSetNonce(nonce)
+		if wantReason, wantOK := window.CheckAndSetNonce(nonce); reason != wantReason || ok != wantOK {
+			t.Errorf("CheckAndSetNonce(%d) = %s, %t, want %s, %t", nonce, reason, ok, wantReason, wantOK)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package slidingwindow
+
+// Bitmap is the constraint for the bitmaps backing a Window. Bit numbering is big-endian like that of Int256.SetBit.
+type Bitmap interface {
+	Int128 | Int256 | Int512
+}
+
+// Window implements the sliding window algorithm with the window size chosen at compile time by its bitmap type:
+// Window[Int128] tracks 128 nonces, Window[Int512] 512. Window[Int256] makes the same decisions as a SlidingWindow of
+// DefaultWindowSize, both share their implementation with WideWindow. Window has none of the options of
+// SlidingWindow, use SlidingWindow unless the window size needs to differ. The zero value is an empty window starting
+// at offset 0. It is not synchronized.
+type Window[T Bitmap] struct {
+	core
+	bitmap T
+}
+
+// NewWindow returns an empty Window starting at startOffset, see NewSlidingWindow.
+func NewWindow[T Bitmap](startOffset uint64) *Window[T] {
+	return &Window[T]{
+		core: core{offset: startOffset},
+	}
+}
+
+// CheckAndSetNonce returns true if the nonce is valid, false otherwise. It updates the Window to prevent the nonce from
+// being valid in the future.
+func (window *Window[T]) CheckAndSetNonce(nonce uint64) (Reason, bool) {
+	words := window.words()
+	reason, ok := window.check(words, nonce, window.offset)
+	if ok {
+		window.set(words, nonce)
+	}
+	return reason, ok
+}
+
+// CheckNonce returns true if the nonce is valid. It does not change the state.
+func (window *Window[T]) CheckNonce(nonce uint64) (Reason, bool) {
+	return window.check(window.words(), nonce, window.offset)
+}
+
+// WindowSize returns the number of nonces tracked by the window, 64 per word of its bitmap.
+func (window *Window[T]) WindowSize() int {
+	return len(window.words()) * 64
+}
+
+// Offset returns the nonce represented by bit 0 of the bitmap. Nonces below it are out of window.
+func (window *Window[T]) Offset() uint64 {
+	return window.offset
+}
+
+// Bitmap returns a copy of the bitmap. Bit n is set if nonce Offset()+n has been seen.
+func (window *Window[T]) Bitmap() T {
+	return window.bitmap
+}
+
+// words returns the words of the bitmap, so they can be changed in place. The array types of Bitmap have no common
+// type that could be sliced directly.
+func (window *Window[T]) words() []uint64 {
+	switch bitmap := any(&window.bitmap).(type) {
+	case *Int128:
+		return bitmap[:]
+	case *Int256:
+		return bitmap[:]
+	case *Int512:
+		return bitmap[:]
+	}
+	panic("slidingwindow: unsupported bitmap type")
+}
+
+// core implements the sliding window algorithm shared by SlidingWindow, WideWindow and Window. It keeps the offset and
+// size while the bitmap words are owned by the embedding window, which passes them to each call.
+type core struct {
+	offset uint64
+	size   uint64 // 0 means all bits of the bitmap
+}
+
+// windowSize returns the number of nonces tracked with the bitmap words w.
+func (window *core) windowSize(w []uint64) uint64 {
+	if window.size == 0 {
+		return uint64(len(w)) * 64
+	}
+	return window.size
+}
+
+// check returns the decision for nonce if the window started at offset, using the bitmap words w. It must not change
+// the state.
+func (window *core) check(w []uint64, nonce, offset uint64) (Reason, bool) {
+	// Is the nonce on the left of the window and hence invalid?
+	if nonce < offset {
+		return ReasonOutOfWindow, false
+	}
+	// Is the nonce on the right of the window?
+	// Compare the distance to the offset, offset+windowSize overflows for windows at the top of the uint64 range.
+	if nonce-offset >= window.windowSize(w) {
+		return ReasonShift, true
+	}
+	// Nonce is within the window.
+	if isBitSetWords(w, nonce-offset) {
+		return ReasonReuse, false
+	}
+	return ReasonFirst, true
+}
+
+// set records nonce in the bitmap words w. The nonce must not be below the window, if it is on the right of it the
+// window is shifted first so the nonce becomes its last bit. It returns the offset before the shift.
+func (window *core) set(w []uint64, nonce uint64) (oldOffset uint64) {
+	oldOffset = window.offset
+	if size := window.windowSize(w); nonce-window.offset >= size {
+		window.offset = nonce - size + 1
+		shiftLeftWords(w, window.offset-oldOffset)
+	}
+	setBitWords(w, nonce-window.offset)
+	return oldOffset
+}
@@ -0,0 +1,92 @@
+package slidingwindow
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// referenceWindow is a straightforward model of the sliding window algorithm. It shares no code with the window types,
+// which are checked against it.
+type referenceWindow struct {
+	offset uint64
+	size   uint64
+	seen   map[uint64]bool
+}
+
+func (ref *referenceWindow) CheckAndSetNonce(nonce uint64) (Reason, bool) {
+	switch {
+	case nonce < ref.offset:
+		return ReasonOutOfWindow, false
+	case ref.seen[nonce]:
+		return ReasonReuse, false
+	case nonce-ref.offset >= ref.size:
+		ref.offset = nonce - ref.size + 1
+		ref.seen[nonce] = true
+		return ReasonShift, true
+	}
+	ref.seen[nonce] = true
+	return ReasonFirst, true
+}
+
+// checkReference applies the same random nonces to window and a referenceWindow of size starting at 0, and reports the
+// first differing decision.
+func checkReference(t *testing.T, window NonceChecker, size int) {
+	t.Helper()
+	ref := &referenceWindow{size: uint64(size), seen: make(map[uint64]bool)}
+	rng := rand.New(rand.NewSource(1))
+	var highest uint64
+	for i := 0; i < 20000; i++ {
+		// Mostly moving forward with nonces around the window, sometimes jumping beyond it.
+		if rng.Intn(100) == 0 {
+			highest += uint64(size + rng.Intn(3*size))
+		} else {
+			highest += uint64(rng.Intn(size / 2))
+		}
+		nonce := highest - uint64(rng.Intn(size+size/2))
+		if nonce > highest {
+			nonce = 0
+		}
+		want, wantOK := ref.CheckAndSetNonce(nonce)
+		if reason, ok := window.CheckAndSetNonce(nonce); reason != want || ok != wantOK {
+			t.Fatalf("step %d: CheckAndSetNonce(%d) = %s, %t, want %s, %t", i, nonce, reason, ok, want, wantOK)
+		}
+	}
+}
+
+func TestWindowsReference(t *testing.T) {
+	newSliding := func(size int) NonceChecker {
+		window, err := NewSlidingWindowSize(0, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return window
+	}
+	newWide := func(size int) NonceChecker {
+		window, err := NewWideWindow(0, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return window
+	}
+	tests := []struct {
+		name   string
+		window func(size int) NonceChecker
+		sizes  []int
+	}{
+		{"SlidingWindow", newSliding, []int{2, 63, 64, 100, 256}},
+		{"WideWindow", newWide, []int{2, 100, 256, 300, 512, 1024}},
+		{"Window[Int128]", func(int) NonceChecker { return new(Window[Int128]) }, []int{128}},
+		{"Window[Int256]", func(int) NonceChecker { return new(Window[Int256]) }, []int{256}},
+		{"Window[Int512]", func(int) NonceChecker { return new(Window[Int512]) }, []int{512}},
+		{"zero SlidingWindow", func(int) NonceChecker { return new(SlidingWindow) }, []int{DefaultWindowSize}},
+		{"zero WideWindow", func(int) NonceChecker { return new(WideWindow) }, []int{DefaultWindowSize}},
+	}
+	for _, test := range tests {
+		for _, size := range test.sizes {
+			t.Run(fmt.Sprintf("%s/%d", test.name, size), func(t *testing.T) {
+				checkReference(t, test.window(size), size)
+			})
+		}
+	}
+}
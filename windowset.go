@@ -0,0 +1,145 @@
+package slidingwindow
+
+import (
+	"encoding/binary"
+	"sort"
+	"time"
+)
+
+// WindowSet keeps one SlidingWindow per sender, keyed by an identifier. Windows are created on first use. The zero
+// value is an empty set. It is not synchronized.
+type WindowSet struct {
+	windows map[string]*windowSetEntry
+}
+
+// windowSetEntry is a window in a WindowSet together with the time it was last used.
+type windowSetEntry struct {
+	window     *SlidingWindow
+	lastAccess time.Time
+}
+
+// CheckAndSetNonce calls CheckAndSetNonce on the window for id, creating it if it doesn't exist yet.
+func (ws *WindowSet) CheckAndSetNonce(id string, nonce uint64) (Reason, bool) {
+	return ws.window(id).CheckAndSetNonce(nonce)
+}
+
+// PeerNonce is a nonce together with the id of its sender, see WindowSet.CheckAndSetNonceBatch.
+type PeerNonce struct {
+	ID    string
+	Nonce uint64
+}
+
+// CheckAndSetNonceBatch applies CheckAndSetNonce to entries in order and returns whether each nonce was accepted.
+// Entries of the same sender affect each other like in SlidingWindow.CheckAndSetNonces, a nonce that shifts a window
+// can make later, smaller nonces of the same sender out of window.
+func (ws *WindowSet) CheckAndSetNonceBatch(entries []PeerNonce) []bool {
+	results := make([]bool, len(entries))
+	var lastID string
+	var window *SlidingWindow
+	for i, entry := range entries {
+		// Consecutive entries of the same sender need only one lookup.
+		if window == nil || entry.ID != lastID {
+			lastID, window = entry.ID, ws.window(entry.ID)
+		}
+		_, results[i] = window.CheckAndSetNonce(entry.Nonce)
+	}
+	return results
+}
+
+// Delete removes the window for id.
+func (ws *WindowSet) Delete(id string) {
+	delete(ws.windows, id)
+}
+
+// Len returns the number of windows in the set.
+func (ws *WindowSet) Len() int {
+	return len(ws.windows)
+}
+
+// ForEach calls fn for each window in the set in unspecified order until fn returns false. It does not count as an
+// access for PruneBefore. fn may delete the current id but must not add windows. WindowSet is not synchronized, callers
+// sharing a set must hold their lock for the whole iteration.
+func (ws *WindowSet) ForEach(fn func(id string, window *SlidingWindow) bool) {
+	for id, entry := range ws.windows {
+		if !fn(id, entry.window) {
+			return
+		}
+	}
+}
+
+// PruneBefore removes all windows that have last been used before t and returns how many were removed. Call it
+// periodically with time.Now().Add(-ttl) to evict idle senders.
+func (ws *WindowSet) PruneBefore(t time.Time) int {
+	var pruned int
+	for id, entry := range ws.windows {
+		if entry.lastAccess.Before(t) {
+			delete(ws.windows, id)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// window returns the window for id, creating it if it doesn't exist yet, and updates its last access time.
+func (ws *WindowSet) window(id string) *SlidingWindow {
+	entry, ok := ws.windows[id]
+	if !ok {
+		if ws.windows == nil {
+			ws.windows = make(map[string]*windowSetEntry)
+		}
+		entry = &windowSetEntry{window: new(SlidingWindow)}
+		ws.windows[id] = entry
+	}
+	entry.lastAccess = time.Now()
+	return entry.window
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The encoding is the number of windows followed by the id and
+// state of each window in order of their ids: the id length, the id and the SlidingWindow.MarshalBinary encoding. The
+// counts and lengths are uvarints. Last access times are not encoded.
+func (ws *WindowSet) MarshalBinary() ([]byte, error) {
+	ids := make([]string, 0, len(ws.windows))
+	for id := range ws.windows {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	b := binary.AppendUvarint(nil, uint64(len(ids)))
+	for _, id := range ids {
+		b = binary.AppendUvarint(b, uint64(len(id)))
+		b = append(b, id...)
+		b, _ = ws.windows[id].window.AppendBinary(b)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces the windows of the set with those encoded by
+// MarshalBinary, counting as accessed now. It returns ErrInvalidLength for truncated data and leaves the set unchanged
+// on any error.
+func (ws *WindowSet) UnmarshalBinary(data []byte) error {
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return ErrInvalidLength
+	}
+	data = data[n:]
+	now := time.Now()
+	windows := make(map[string]*windowSetEntry)
+	for ; count > 0; count-- {
+		idLength, n := binary.Uvarint(data)
+		if n <= 0 || idLength > uint64(len(data)-n) || uint64(len(data)-n)-idLength < binaryLength {
+			return ErrInvalidLength
+		}
+		id := string(data[n : n+int(idLength)])
+		data = data[n+int(idLength):]
+		window := new(SlidingWindow)
+		if err := window.UnmarshalBinary(data[:binaryLength]); err != nil {
+			return err
+		}
+		data = data[binaryLength:]
+		windows[id] = &windowSetEntry{window: window, lastAccess: now}
+	}
+	if len(data) != 0 {
+		return ErrInvalidLength
+	}
+	ws.windows = windows
+	return nil
+}
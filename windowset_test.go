@@ -0,0 +1,50 @@
+package slidingwindow
+
+import (
+	"testing"
+)
+
+func TestWindowSetBinary(t *testing.T) {
+	var set WindowSet
+	set.CheckAndSetNonce("a", 5)
+	set.CheckAndSetNonce("a", 1000)
+	set.CheckAndSetNonce("bb", 7)
+	set.CheckAndSetNonce("", 0)
+	data, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded WindowSet
+	decoded.CheckAndSetNonce("replaced", 1)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Len() != set.Len() {
+		t.Errorf("UnmarshalBinary() decoded %d windows, want %d", decoded.Len(), set.Len())
+	}
+	set.ForEach(func(id string, window *SlidingWindow) bool {
+		if got := decoded.windows[id]; got == nil || !got.window.Equal(window) {
+			t.Errorf("UnmarshalBinary() window %q = %v, want %s", id, got, window)
+		}
+		return true
+	})
+
+	// Truncated data and trailing bytes are rejected and leave the set unchanged.
+	for n := 0; n < len(data); n++ {
+		if err := decoded.UnmarshalBinary(data[:n]); err != ErrInvalidLength {
+			t.Errorf("UnmarshalBinary() of %d of %d bytes: error = %v, want %v", n, len(data), err, ErrInvalidLength)
+		}
+	}
+	if err := decoded.UnmarshalBinary(append(data, 0)); err != ErrInvalidLength {
+		t.Errorf("UnmarshalBinary() with trailing byte: error = %v, want %v", err, ErrInvalidLength)
+	}
+	if decoded.Len() != set.Len() {
+		t.Errorf("UnmarshalBinary() of invalid data changed the set to %d windows", decoded.Len())
+	}
+
+	var empty WindowSet
+	if data, err := empty.MarshalBinary(); err != nil || len(data) != 1 || data[0] != 0 {
+		t.Errorf("MarshalBinary() of an empty set = %x, %v, want 00, nil", data, err)
+	}
+}